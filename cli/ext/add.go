@@ -1,18 +1,47 @@
 package ext
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"pig/internal/config"
 	"pig/internal/utils"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	EnableRepos       []string  // repos to be enabled temporarily during install
+	DisableRepos      []string  // repos to be disabled temporarily during install
+	PostInstallHook   string    // command to be run after successful install
+	IgnoreHookErrors  bool      // do not fail if post install hook exits non-zero
+	SimulateResolve   bool      // print packages resolved by backend dry-run and stop without installing
+	Quiet             bool      // suppress install time summary
+	Verbose           bool      // include package manager stderr in install failure, and package signers
+	Summary           = true    // print a summary line after install
+	PreferVersion     = "exact" // pg version policy if extension is not available: exact, nearest, or a major version
+	PgVersionGiven    bool      // pg major version is given explicitly, so the pgsql target is not resolved to the latest kernel
+	ExcludeRecommends bool      // do not install recommended (weak) dependencies
+)
+
+// stderrTailLines is the number of package manager stderr lines kept for install failure
+const stderrTailLines = 20
+
+// InstallUnit is a logical install target (an extension or alias) and its packages
+type InstallUnit struct {
+	Name     string
+	Packages []string
+}
+
 // InstallExtensions installs extensions based on provided names, aliases, or categories
-func InstallExtensions(pgVer int, names []string, yes bool) error {
+func InstallExtensions(pgVer int, names []string, yes bool) (err error) {
 	logrus.Debugf("installing extensions: pgVer=%d, names=%s, yes=%v", pgVer, strings.Join(names, ", "), yes)
 	if len(names) == 0 {
 		return fmt.Errorf("no extension names provided")
@@ -21,31 +50,34 @@ func InstallExtensions(pgVer int, names []string, yes bool) error {
 		logrus.Debugf("no PostgreSQL version specified, set target version to the latest major version: %d", PostgresLatestMajorVersion)
 		pgVer = PostgresLatestMajorVersion
 	}
+	report := newReport("install", pgVer, names)
+	defer func() {
+		if !SimulateResolve {
+			report.finish(err)
+		}
+	}()
 
-	var installCmds []string
+	backend, err := GetBackend()
+	if err != nil {
+		return err
+	}
+	if checker, ok := backend.(optionChecker); ok {
+		if err := checker.CheckOptions(); err != nil {
+			return err
+		}
+	}
 	Catalog.LoadAliasMap(config.OSType)
-	switch config.OSType {
-	case config.DistroEL:
-		installCmds = append(installCmds, []string{"yum", "install"}...)
-		if config.OSVersion == "8" || config.OSVersion == "9" {
-			installCmds[0] = "dnf"
-		}
-		if yes {
-			installCmds = append(installCmds, "-y")
-		}
-	case config.DistroDEB:
-		installCmds = append(installCmds, []string{"apt-get", "install"}...)
-		if yes {
-			installCmds = append(installCmds, "-y")
-		}
-	case config.DistroMAC:
-		logrus.Warnf("macOS brew installation is not supported yet")
-		os.Exit(1)
-	default:
-		return fmt.Errorf("unsupported OS type: %s", config.OSType)
+
+	pins, err := LoadPins()
+	if err != nil {
+		logrus.Warnf("failed to load extension pins: %v", err)
 	}
 
 	var pkgNames []string
+	var exts []*Extension
+	var units []*InstallUnit
+	versions := make(map[string]string)
+	names = Catalog.ExpandBundles(names)
 	for _, name := range names {
 		// package version is specified in (name=version format)
 		var version string
@@ -53,57 +85,444 @@ func InstallExtensions(pgVer int, names []string, yes bool) error {
 			name = parts[0]
 			version = parts[1]
 		}
-		ext, ok := Catalog.ExtNameMap[name]
-		if !ok {
-			ext, ok = Catalog.ExtAliasMap[name]
-		}
+		ext, ok := lookupExtension(name)
 		if !ok {
 			// try to find in AliasMap (if it is not a postgres extension)
 			if pgPkg, ok := Catalog.AliasMap[name]; ok {
-				pkgNamesProcessed := processPkgName(pgPkg, pgVer)
-				if version != "" {
-					for i, pkg := range pkgNamesProcessed {
-						if config.OSType == config.DistroEL {
-							pkgNamesProcessed[i] = fmt.Sprintf("%s-%s", pkg, version)
-						} else if config.OSType == config.DistroDEB {
-							pkgNamesProcessed[i] = fmt.Sprintf("%s=%s*", pkg, version)
-						}
+				aliasPgVer := pgVer
+				if name == "pgsql" && !PgVersionGiven {
+					if aliasPgVer, err = latestKernelVersion(yes); err != nil {
+						return err
 					}
 				}
+				pkgNamesProcessed := backend.Resolve(pgPkg, version, aliasPgVer)
 				pkgNames = append(pkgNames, pkgNamesProcessed...)
+				units = append(units, &InstallUnit{Name: name, Packages: pkgNamesProcessed})
 				continue
-			} else {
+			}
+			// try to resolve partial name with fuzzy search
+			ext, ok = resolvePartialName(name, yes)
+			if !ok {
 				logrus.Debugf("can not found '%s' in extension name or alias", name)
 				continue
 			}
 		}
-		pkgName := ext.PackageName(pgVer)
+		if pinVer, pinned := pins[ext.Name]; pinned {
+			if version != "" && version != pinVer {
+				return fmt.Errorf("extension %s is pinned to %s, unpin it before installing %s", ext.Name, pinVer, version)
+			}
+			logrus.WithFields(logrus.Fields{"extension": ext.Name, "version": pinVer}).Infof("extension %s is pinned to version %s", ext.Name, pinVer)
+			version = pinVer
+		}
+		if ext.Deprecated {
+			logrus.WithFields(logrus.Fields{"extension": ext.Name, "replacement": ext.Replacement}).Warnf("%s: %s", ext.Name, ext.DeprecatedNote())
+		}
+		pkgPgVer, err := packagePgVersion(ext, pgVer)
+		if err != nil {
+			return err
+		}
+		pkgName := ext.PackageName(pkgPgVer)
 		if pkgName == "" {
-			logrus.Warnf("no package found for extension %s", ext.Name)
+			logrus.WithFields(logrus.Fields{"extension": ext.Name, "pg_version": pkgPgVer}).Warnf("no package found for extension %s", ext.Name)
 			continue
 		}
-		logrus.Debugf("translate extension %s to package name: %s", ext.Name, pkgName)
+		logrus.WithFields(logrus.Fields{"extension": ext.Name, "package": pkgName, "pg_version": pkgPgVer}).Debugf("translate extension %s to package name: %s", ext.Name, pkgName)
 
-		pkgNamesProcessed := processPkgName(pkgName, pgVer)
-		if version != "" {
-			for i, pkg := range pkgNamesProcessed {
-				if config.OSType == config.DistroEL {
-					pkgNamesProcessed[i] = fmt.Sprintf("%s-%s", pkg, version)
-				} else if config.OSType == config.DistroDEB {
-					pkgNamesProcessed[i] = fmt.Sprintf("%s=%s*", pkg, version)
-				}
-			}
-		}
+		pkgNamesProcessed := backend.Resolve(pkgName, version, pkgPgVer)
 		pkgNames = append(pkgNames, pkgNamesProcessed...)
+		exts = append(exts, ext)
+		if version == "" {
+			version = ext.PgPackageVersion(pkgPgVer)
+		}
+		versions[ext.Name] = version
+		units = append(units, &InstallUnit{Name: ext.Name, Packages: pkgNamesProcessed})
 	}
 
+	if err := checkConflicts(exts); err != nil {
+		return err
+	}
+	if DependencyOrder {
+		if exts, err = SortByDependency(exts); err != nil {
+			return err
+		}
+		units = sortUnits(units, exts)
+		pkgNames = nil
+		for _, unit := range units {
+			pkgNames = append(pkgNames, unit.Packages...)
+		}
+	}
+	pkgNames = dedupPkgNames(pkgNames)
 	if len(pkgNames) == 0 {
 		return fmt.Errorf("no packages to be installed")
 	}
-	installCmds = append(installCmds, pkgNames...)
-	logrus.Infof("installing extensions: %s", strings.Join(installCmds, " "))
+	repoArgs, cleanupRepo, err := setupRepoURL()
+	if err != nil {
+		return err
+	}
+	repoURLArgs = repoArgs
+	defer func() { cleanupRepo(); repoURLArgs = nil }()
+	if SimulateResolve {
+		resolved, err := simulateInstall(backend, pkgNames)
+		if err != nil {
+			return err
+		}
+		logrus.Infof("resolved %d packages for PostgreSQL %d on %s.%s", len(resolved), pgVer, config.OSCode, config.OSArch)
+		for _, pkg := range resolved {
+			fmt.Println(pkg)
+		}
+		if ShowPreloadDiff {
+			_, err := handlePreload(exts)
+			return err
+		}
+		return nil
+	}
+	warnUnsignedSources()
+	logger := logrus.WithFields(logrus.Fields{"extensions": names, "packages": pkgNames, "pg_version": pgVer})
+	var done []*InstallUnit
+	var elapsed time.Duration
+	if !Quiet {
+		defer func() { PrintInstallSummary(done, elapsed) }()
+	}
+	// keep packages installed before, so a rollback removes only what this install added
+	prior := &ReportItem{Packages: pkgNames}
+	recordPrior(backend, []*ReportItem{prior})
+	targets := pkgNames
+	if UseCache {
+		targets = cachePackages(pkgNames)
+	}
+	// install all packages in one transaction with a single confirmation, units are kept for reporting only
+	logger.Debugf("installing packages: %s", strings.Join(targets, " "))
+	start := time.Now()
+	installErr := backend.Install(targets, yes)
+	elapsed = time.Since(start)
+	if installErr != nil {
+		logger.WithError(installErr).Errorf("failed to install packages")
+		var unitNames []string
+		for _, unit := range units {
+			unitNames = append(unitNames, unit.Name)
+			report.Failed = append(report.Failed, &ReportItem{Name: unit.Name, Version: versions[unit.Name], Packages: unit.Packages})
+		}
+		return installError(installErr, strings.Join(unitNames, ", "), pkgNames, pgVer)
+	}
+	done = units
+	if Verbose {
+		logSigners(pkgNames)
+	}
+	for _, unit := range units {
+		item := &ReportItem{Name: unit.Name, Version: versions[unit.Name], Packages: unit.Packages}
+		recordAdded(backend, item, prior.Prior)
+		report.Succeeded = append(report.Succeeded, item)
+	}
+	logger.Infof("installed extensions: %s", strings.Join(names, ", "))
+	preloadChanged := false
+	if ShowPreloadDiff || EditPreload {
+		if preloadChanged, err = handlePreload(exts); err != nil {
+			return err
+		}
+	}
+	restart := restartNeeded(exts)
+	printRestartNotice(restart, preloadChanged)
+	if Summary {
+		fmt.Println(installSummary(exts, versions, pgVer, len(restart)))
+	}
+	var installedPkgs []string
+	for _, unit := range done {
+		installedPkgs = append(installedPkgs, unit.Packages...)
+	}
+	// packages are installed anyway, a failed restart is logged instead of failing the install
+	if err := recommendRestart(restartReasons(installedPkgs, restart, preloadChanged), pgVer, yes); err != nil {
+		logrus.Warn(err)
+	}
+	return nil
+}
+
+// simulateInstall returns the packages a backend dry-run would install, dependencies included
+// the catalog package list is returned as is if the backend can not simulate, or the os is forced
+func simulateInstall(backend PackageBackend, pkgNames []string) ([]string, error) {
+	simulator, ok := backend.(installSimulator)
+	if !ok || config.ForcedOSMismatch() {
+		logrus.Debugf("backend dry-run not available, print catalog packages without dependencies")
+		return pkgNames, nil
+	}
+	return simulator.SimulateInstall(pkgNames)
+}
+
+// latestKernelVersion returns the highest PostgreSQL major whose kernel package is available in configured repos
+// it warns, and asks unless yes, if another PostgreSQL major is already installed
+func latestKernelVersion(yes bool) (int, error) {
+	latest := 0
+	for _, v := range PostgresActiveMajorVersions {
+		pkgs := processPkgName(Catalog.AliasMap["pgsql"], v)
+		if len(pkgs) > 0 && len(queryRepoVersions(pkgs[0])) > 0 {
+			latest = v
+			break
+		}
+	}
+	if latest == 0 {
+		latest = PostgresLatestMajorVersion
+		logrus.Warnf("no PostgreSQL kernel found in configured repos, assume the latest major version %d", latest)
+	} else {
+		logrus.Infof("pgsql resolved to PostgreSQL %d, the latest major version available in configured repos", latest)
+	}
+	var others []string
+	_, installs, err := FindPostgres()
+	if err != nil {
+		logrus.Debugf("failed to detect installed PostgreSQL: %v", err)
+	}
+	for _, pi := range installs {
+		if pi.MajorVersion != latest {
+			others = append(others, strconv.Itoa(pi.MajorVersion))
+		}
+	}
+	if len(others) > 0 {
+		sort.Strings(others)
+		logrus.Warnf("PostgreSQL %s already installed, installing PostgreSQL %d as another major version", strings.Join(others, ", "), latest)
+		if !yes && !utils.Confirm(fmt.Sprintf("install PostgreSQL %d alongside?", latest)) {
+			return 0, fmt.Errorf("installing PostgreSQL %d is canceled, use -v to choose a major version", latest)
+		}
+	}
+	return latest, nil
+}
+
+// CheckPreferVersion validates the pg version fallback policy: exact, nearest, or a major version
+func CheckPreferVersion(policy string) error {
+	if policy == "exact" || policy == "nearest" {
+		return nil
+	}
+	if _, err := strconv.Atoi(policy); err != nil {
+		return fmt.Errorf("invalid --prefer-version %q, should be exact, nearest or a major version", policy)
+	}
+	return nil
+}
+
+// packagePgVersion returns the pg version whose package is installed for the extension according to PreferVersion
+// the given version is used if the extension is available for it, otherwise fallback is made loudly, or an error is returned
+func packagePgVersion(ext *Extension, pgVer int) (int, error) {
+	if ext.Available(pgVer) {
+		return pgVer, nil
+	}
+	supported := ext.SupportedPgVersions()
+	fallback := 0
+	switch PreferVersion {
+	case "nearest":
+		for _, v := range supported {
+			if v < pgVer {
+				fallback = v
+				break
+			}
+		}
+	case "exact", "":
+	default:
+		if v, _ := strconv.Atoi(PreferVersion); slices.Contains(supported, v) {
+			fallback = v
+		}
+	}
+	if fallback == 0 {
+		available := "none"
+		if len(supported) > 0 {
+			available = strings.Trim(fmt.Sprint(supported), "[]")
+		}
+		return 0, fmt.Errorf("extension %s is not available for PostgreSQL %d (available: %s), use --prefer-version nearest to fall back",
+			ext.Name, pgVer, available)
+	}
+	logrus.Warnf("!!! extension %s is not available for PostgreSQL %d, installing the PostgreSQL %d package instead, it may not work with PostgreSQL %d",
+		ext.Name, pgVer, fallback, pgVer)
+	return fallback, nil
+}
+
+// installSummary returns a one line summary of installed extensions and how many of them need a restart
+func installSummary(exts []*Extension, versions map[string]string, pgVer int, restart int) string {
+	var items []string
+	for _, ext := range exts {
+		if v := versions[ext.Name]; v != "" {
+			items = append(items, ext.Name+" "+v)
+		} else {
+			items = append(items, ext.Name)
+		}
+	}
+	noun := "extensions"
+	if len(exts) == 1 {
+		noun = "extension"
+	}
+	summary := fmt.Sprintf("Installed %d %s (%s) for PostgreSQL %d", len(exts), noun, strings.Join(items, ", "), pgVer)
+	if restart > 0 {
+		summary += fmt.Sprintf("; %d requires restart", restart)
+	}
+	return summary
+}
+
+// installError wraps an install failure with the environment, so it can be diagnosed from the message alone
+func installError(err error, unit string, pkgs []string, pgVer int) error {
+	var stderr []string
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		stderr = cmdErr.Stderr
+	}
+	env := fmt.Sprintf("os=%s.%s (%s %s), pg=%d, packages=%s", config.OSCode, config.OSArch, config.OSVendor, config.OSVersionFull, pgVer, strings.Join(pkgs, " "))
+	if !Verbose || len(stderr) == 0 {
+		return fmt.Errorf("failed to install %s: %w (%s)", unit, err, env)
+	}
+	return fmt.Errorf("failed to install %s: %w (%s), stderr: %s", unit, err, env, strings.Join(stderr, " | "))
+}
+
+// PrintInstallSummary prints packages of each extension and the time of the install transaction
+// extensions are installed in one transaction, so there is no per extension time to report
+func PrintInstallSummary(units []*InstallUnit, elapsed time.Duration) {
+	if len(units) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nExtension\tPackages")
+	fmt.Fprintln(w, "---------\t--------")
+	for _, unit := range units {
+		fmt.Fprintf(w, "%s\t%s\n", unit.Name, strings.Join(unit.Packages, " "))
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows) (Total: %s)\n\n", len(units), elapsed.Round(100*time.Millisecond))
+}
+
+// checkConflicts checks requested extensions against each other and installed extensions for declared conflicts
+func checkConflicts(exts []*Extension) error {
+	requested := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		requested[ext.Name] = true
+	}
+	var conflicts []string
+	for _, ext := range exts {
+		for _, other := range ext.Conflicts {
+			if requested[other] && ext.Name < other { // report each requested pair once
+				conflicts = append(conflicts, fmt.Sprintf("%s conflicts with %s (requested)", ext.Name, other))
+			}
+			if Postgres != nil && Postgres.ExtensionMap[other] != nil && !requested[other] {
+				conflicts = append(conflicts, fmt.Sprintf("%s conflicts with %s (installed)", ext.Name, other))
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		for _, c := range conflicts {
+			logrus.Warn(c)
+		}
+		return fmt.Errorf("conflicting extensions found: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
 
-	return utils.SudoCommand(installCmds)
+// InstallExtensionsMulti installs extensions for multiple PostgreSQL major versions and reports per-version result
+func InstallExtensionsMulti(pgVers []int, names []string, yes bool) error {
+	results := make(map[int]error, len(pgVers))
+	for _, pgVer := range pgVers {
+		logrus.Infof("installing extensions for PostgreSQL %d", pgVer)
+		results[pgVer] = InstallExtensions(pgVer, names, yes)
+		if errors.Is(results[pgVer], utils.ErrInterrupted) {
+			return results[pgVer]
+		}
+	}
+
+	var failed []string
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nPG Version\tResult")
+	fmt.Fprintln(w, "----------\t------")
+	for _, pgVer := range pgVers {
+		if err := results[pgVer]; err != nil {
+			fmt.Fprintf(w, "%d\tfailed: %v\n", pgVer, err)
+			failed = append(failed, strconv.Itoa(pgVer))
+		} else {
+			fmt.Fprintf(w, "%d\tsuccess\n", pgVer)
+		}
+	}
+	w.Flush()
+	if len(failed) > 0 {
+		return fmt.Errorf("failed on PostgreSQL %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// RunPostInstallHook runs the post install hook command with installed extension names in PIG_INSTALLED_EXTS
+func RunPostInstallHook(names []string) error {
+	if PostInstallHook == "" {
+		return nil
+	}
+	var extNames []string
+	for _, name := range names {
+		name, _, _ = strings.Cut(name, "=")
+		if ext, ok := lookupExtension(name); ok {
+			name = ext.Name
+		}
+		extNames = append(extNames, name)
+	}
+	logrus.Infof("running post install hook: %s", PostInstallHook)
+	cmd := exec.Command("sh", "-c", PostInstallHook)
+	cmd.Env = append(os.Environ(), "PIG_INSTALLED_EXTS="+strings.Join(extNames, " "))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if IgnoreHookErrors {
+			logrus.Warnf("post install hook failed, ignored: %v", err)
+			return nil
+		}
+		return fmt.Errorf("post install hook failed: %v", err)
+	}
+	return nil
+}
+
+// resolvePartialName resolves a partial or ambiguous extension name with prefix match & search
+// numbered choices are presented if multiple extensions matched, with yes, only unique match is accepted
+func resolvePartialName(name string, yes bool) (*Extension, bool) {
+	var matches []*Extension
+	for _, ext := range Catalog.Extensions {
+		if strings.HasPrefix(ext.Name, name) {
+			matches = append(matches, ext)
+		}
+	}
+	if len(matches) == 0 {
+		matches = SearchExtensions(name, Catalog.Extensions)
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+	if yes {
+		if len(matches) == 1 {
+			logrus.Infof("resolve '%s' to extension %s", name, matches[0].Name)
+			return matches[0], true
+		}
+		var candidates []string
+		for _, m := range matches {
+			candidates = append(candidates, m.Name)
+		}
+		logrus.Warnf("'%s' is ambiguous, candidates: %s", name, strings.Join(candidates, ", "))
+		return nil, false
+	}
+	if len(matches) == 1 {
+		if utils.Confirm(fmt.Sprintf("extension '%s' not found, install %s instead?", name, matches[0].Name)) {
+			return matches[0], true
+		}
+		return nil, false
+	}
+	fmt.Printf("extension '%s' not found, did you mean:\n", name)
+	for i, m := range matches {
+		fmt.Printf("  %2d) %-24s %s\n", i+1, m.Name, m.EnDesc)
+	}
+	answer := utils.Prompt(fmt.Sprintf("choose one to install [1-%d], or press enter to skip: ", len(matches)))
+	idx, err := strconv.Atoi(answer)
+	if err != nil || idx < 1 || idx > len(matches) {
+		logrus.Infof("skip '%s'", name)
+		return nil, false
+	}
+	return matches[idx-1], true
+}
+
+// dedupPkgNames removes duplicate package names while preserving order
+func dedupPkgNames(pkgNames []string) []string {
+	seen := make(map[string]bool, len(pkgNames))
+	result := make([]string, 0, len(pkgNames))
+	for _, pkg := range pkgNames {
+		if !seen[pkg] {
+			seen[pkg] = true
+			result = append(result, pkg)
+		}
+	}
+	return result
 }
 
 // processPkgName processes the package name and returns the list of package names according to the given version
@@ -0,0 +1,101 @@
+package ext
+
+import (
+	"pig/internal/config"
+	"testing"
+)
+
+func TestInstallSummary(t *testing.T) {
+	exts := []*Extension{{Name: "postgis"}, {Name: "vector"}, {Name: "pg_cron"}}
+	versions := map[string]string{"postgis": "3.4.2", "vector": "0.7.0", "pg_cron": "1.6.2"}
+	want := "Installed 3 extensions (postgis 3.4.2, vector 0.7.0, pg_cron 1.6.2) for PostgreSQL 16; 1 requires restart"
+	if got := installSummary(exts, versions, 16, 1); got != want {
+		t.Errorf("installSummary() = %q, want %q", got, want)
+	}
+	want = "Installed 1 extension (vector) for PostgreSQL 17"
+	if got := installSummary(exts[1:2], nil, 17, 0); got != want {
+		t.Errorf("installSummary() = %q, want %q", got, want)
+	}
+}
+
+func TestPackagePgVersion(t *testing.T) {
+	savedOS, savedPolicy := config.OSType, PreferVersion
+	defer func() { config.OSType, PreferVersion = savedOS, savedPolicy }()
+	config.OSType = config.DistroDEB
+	e := &Extension{Name: "repmgr", DebPkg: "postgresql-$v-repmgr", DebPg: []string{"16", "15", "14", "13"}}
+	tests := []struct {
+		policy string
+		pgVer  int
+		want   int
+	}{
+		{"exact", 16, 16},
+		{"exact", 17, 0},
+		{"nearest", 17, 16},
+		{"nearest", 12, 0},
+		{"14", 17, 14},
+		{"12", 17, 0},
+	}
+	for _, tt := range tests {
+		PreferVersion = tt.policy
+		got, err := packagePgVersion(e, tt.pgVer)
+		if (err != nil) != (tt.want == 0) || got != tt.want {
+			t.Errorf("packagePgVersion(%s, %d) = %d, %v, want %d", tt.policy, tt.pgVer, got, err, tt.want)
+		}
+	}
+}
+
+func TestPgPackageVersion(t *testing.T) {
+	savedOS := config.OSType
+	defer func() { config.OSType = savedOS }()
+	e := &Extension{Name: "postgis", Version: "3.5.0", RpmVer: "3.5.0", DebVer: "3.5.1",
+		RpmPgVer: map[int]string{12: "3.3.7"}, DebPgVer: map[int]string{12: "3.3.8", 13: "3.4.4"}}
+	tests := []struct {
+		os    string
+		pgVer int
+		want  string
+	}{
+		{config.DistroEL, 17, "3.5.0"},
+		{config.DistroEL, 12, "3.3.7"},
+		{config.DistroEL, 13, "3.5.0"},
+		{config.DistroDEB, 13, "3.4.4"},
+		{config.DistroDEB, 16, "3.5.1"},
+		{"", 12, "3.5.0"},
+	}
+	for _, tt := range tests {
+		config.OSType = tt.os
+		if got := e.PgPackageVersion(tt.pgVer); got != tt.want {
+			t.Errorf("PgPackageVersion(%q, %d) = %q, want %q", tt.os, tt.pgVer, got, tt.want)
+		}
+	}
+	if got := e.DebVersions(); got != "3.5.1 (pg13: 3.4.4, pg12: 3.3.8)" {
+		t.Errorf("DebVersions() = %q", got)
+	}
+}
+
+func TestRestartReasons(t *testing.T) {
+	savedOS := config.OSType
+	t.Cleanup(func() { config.OSType = savedOS })
+	if got := restartReasons([]string{"postgresql-17-pgvector"}, nil, false); len(got) != 0 {
+		t.Errorf("restartReasons() = %v, want none for extension package", got)
+	}
+	got := restartReasons([]string{"postgresql17-server", "postgresql17"}, []*Extension{{Name: "pg_cron"}}, true)
+	if len(got) != 3 || got[2] != "pg_cron added to shared_preload_libraries" {
+		t.Errorf("restartReasons() = %v", got)
+	}
+	if got := restartReasons(nil, []*Extension{{Name: "pg_cron"}}, false); len(got) != 0 {
+		t.Errorf("restartReasons() = %v, want none if shared_preload_libraries is not changed", got)
+	}
+	config.OSType = config.DistroDEB
+	if c := serviceCandidates(16); c[2] != "postgresql@16-main" {
+		t.Errorf("serviceCandidates(16) = %v", c)
+	}
+}
+
+func TestLatestKernelVersionFallback(t *testing.T) {
+	savedOS := config.OSType
+	defer func() { config.OSType = savedOS }()
+	config.OSType = "" // no package manager to query repos, nor PostgreSQL to detect
+	if v, err := latestKernelVersion(true); err != nil || v != PostgresLatestMajorVersion {
+		t.Errorf("latestKernelVersion() = %d, %v, want %d", v, err, PostgresLatestMajorVersion)
+	}
+}
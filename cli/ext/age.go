@@ -0,0 +1,129 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusAge adds the install age of each extension package to status output
+var StatusAge bool
+
+// dpkgInfoDir is where dpkg keeps per package file lists, whose mtime is the install time
+var dpkgInfoDir = "/var/lib/dpkg/info"
+
+// installTimer is implemented by backends that could tell when installed packages were installed
+type installTimer interface {
+	InstallTimes() (map[string]time.Time, error)
+}
+
+func (b *dnfBackend) InstallTimes() (map[string]time.Time, error) {
+	out, err := exec.Command("rpm", "-qa", "--qf", "%{NAME}\t%{INSTALLTIME}\n").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rpm install time: %v", err)
+	}
+	times := make(map[string]time.Time)
+	for _, line := range strings.Split(string(out), "\n") {
+		name, ts, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if sec, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			times[name] = time.Unix(sec, 0)
+		}
+	}
+	return times, nil
+}
+
+func (b *aptBackend) InstallTimes() (map[string]time.Time, error) {
+	files, err := filepath.Glob(filepath.Join(dpkgInfoDir, "*.list"))
+	if err != nil {
+		return nil, err
+	}
+	times := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		fi, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".list")
+		name, _, _ = strings.Cut(name, ":") // multi-arch packages are listed as name:arch.list
+		times[name] = fi.ModTime()
+	}
+	return times, nil
+}
+
+// ExtensionInstallTimes returns when the main package of each installed extension was installed
+func ExtensionInstallTimes(exts []*ExtensionInstall, pgVer int) (map[string]time.Time, error) {
+	backend, err := GetBackend()
+	if err != nil {
+		return nil, err
+	}
+	timer, ok := backend.(installTimer)
+	if !ok {
+		return nil, fmt.Errorf("package backend does not support install time query")
+	}
+	times, err := timer.InstallTimes()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]time.Time)
+	for _, ei := range exts {
+		if ei.Extension == nil {
+			continue
+		}
+		pkgs := processPkgName(ei.PackageName(pgVer), pgVer)
+		if len(pkgs) == 0 {
+			continue
+		}
+		if t, ok := packageInstallTime(times, pkgs[0]); ok {
+			result[ei.Name] = t
+		}
+	}
+	return result, nil
+}
+
+// packageInstallTime returns the latest install time of packages matching the name or glob pattern
+func packageInstallTime(times map[string]time.Time, pattern string) (time.Time, bool) {
+	if t, ok := times[pattern]; ok {
+		return t, true
+	}
+	var latest time.Time
+	if strings.ContainsAny(pattern, "*?[") {
+		for name, t := range times {
+			if ok, _ := path.Match(pattern, name); ok && t.After(latest) {
+				latest = t
+			}
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// humanAge formats the time elapsed since t as a relative age like "3 days ago"
+func humanAge(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+	unit := func(n int, name string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", name)
+		}
+		return fmt.Sprintf("%d %ss ago", n, name)
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return unit(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return unit(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return unit(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return unit(int(d/(30*24*time.Hour)), "month")
+	}
+	return unit(int(d/(365*24*time.Hour)), "year")
+}
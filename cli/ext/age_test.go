@@ -0,0 +1,57 @@
+package ext
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInstallTimes(t *testing.T) {
+	saved := dpkgInfoDir
+	defer func() { dpkgInfoDir = saved }()
+	dpkgInfoDir = t.TempDir()
+	installed := time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC)
+	for _, name := range []string{"postgresql-16-cron.list", "libc6:amd64.list", "postgresql-16-cron.md5sums"} {
+		file := filepath.Join(dpkgInfoDir, name)
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, installed, installed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	times, err := (&aptBackend{}).InstallTimes()
+	if err != nil {
+		t.Fatalf("InstallTimes() error = %v", err)
+	}
+	if len(times) != 2 || !times["libc6"].Equal(installed) {
+		t.Errorf("InstallTimes() = %v", times)
+	}
+	if got, ok := packageInstallTime(times, "postgresql-16-cr*"); !ok || !got.Equal(installed) {
+		t.Errorf("packageInstallTime(glob) = %v, %v", got, ok)
+	}
+	if _, ok := packageInstallTime(times, "postgresql-16-cron-scripts"); ok {
+		t.Errorf("packageInstallTime() should not match a missing package")
+	}
+}
+
+func TestHumanAge(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{time.Minute, "1 minute ago"},
+		{5 * time.Hour, "5 hours ago"},
+		{3 * 24 * time.Hour, "3 days ago"},
+		{65 * 24 * time.Hour, "2 months ago"},
+		{800 * 24 * time.Hour, "2 years ago"},
+	}
+	for _, tt := range tests {
+		if got := humanAge(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("humanAge(%v) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
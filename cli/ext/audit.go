@@ -0,0 +1,139 @@
+package ext
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// HistoryFileName is the name of the append-only operation log under config dir, one json report per line
+const HistoryFileName = "history.jsonl"
+
+// HistoryFilePath returns the path to the operation history file
+func HistoryFilePath() string {
+	return config.ConfigPath(HistoryFileName)
+}
+
+// appendHistory appends a finished change report to the history file
+func appendHistory(r *Report) error {
+	if config.ConfigDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(config.ConfigDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config dir %s: %v", config.ConfigDir, err)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %v", err)
+	}
+	f, err := os.OpenFile(HistoryFilePath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file %s: %v", HistoryFilePath(), err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file %s: %v", HistoryFilePath(), err)
+	}
+	return nil
+}
+
+// LoadHistory reads operation history entries at or after since, malformed lines are skipped
+func LoadHistory(since time.Time) ([]*Report, error) {
+	entries := []*Report{}
+	if config.ConfigDir == "" {
+		return entries, nil
+	}
+	f, err := os.Open(HistoryFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("failed to read history file %s: %v", HistoryFilePath(), err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var r Report
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		r.ID = line
+		if r.Timestamp.Before(since) {
+			continue
+		}
+		entries = append(entries, &r)
+	}
+	return entries, scanner.Err()
+}
+
+// ParseSince parses a --since value: a duration like 12h or 7d, or a date like 2006-01-02
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, should be a duration like 12h, 7d, or a date like 2006-01-02", s)
+}
+
+// result returns the outcome of a report: ok, failed or partial
+func (r *Report) result() string {
+	switch {
+	case r.Error == "":
+		return "ok"
+	case len(r.Succeeded) > 0:
+		return "partial"
+	}
+	return "failed"
+}
+
+// PrintOperationHistory prints install / remove / update operations performed by pig since given time
+func PrintOperationHistory(since time.Time, output string) error {
+	entries, err := LoadHistory(since)
+	if err != nil {
+		return err
+	}
+	if output == "json" {
+		return utils.PrintJSON(entries)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTime\tAction\tPG\tResult\tExtensions\tUser")
+	fmt.Fprintln(w, "--\t----\t------\t--\t------\t----------\t----")
+	for _, r := range entries {
+		var items []string
+		for _, item := range append(append([]*ReportItem{}, r.Succeeded...), r.Failed...) {
+			if item.Version != "" {
+				items = append(items, item.Name+"="+item.Version)
+			} else {
+				items = append(items, item.Name)
+			}
+		}
+		if len(items) == 0 || r.Action == "rollback" {
+			items = r.Requested // rollback lists undone entry ids
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\t%s\n", r.ID, r.Timestamp.Local().Format("2006-01-02 15:04:05"), r.Action,
+			r.PgVersion, r.result(), strings.Join(items, ","), r.User)
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows) (History: %s)\n\n", len(entries), HistoryFilePath())
+	return nil
+}
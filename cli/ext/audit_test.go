@@ -0,0 +1,96 @@
+package ext
+
+import (
+	"errors"
+	"pig/internal/config"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"12h", now.Add(-12 * time.Hour), false},
+		{"7d", now.AddDate(0, 0, -7), false},
+		{"2025-03-01", time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local), false},
+		{"yesterday", time.Time{}, true},
+		{"-3d", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	saved, savedReports := config.ConfigDir, reports
+	defer func() { config.ConfigDir, reports = saved, savedReports }()
+	config.ConfigDir = t.TempDir()
+
+	old := newReport("install", 16, []string{"postgis"})
+	old.Timestamp = time.Now().Add(-48 * time.Hour)
+	old.Succeeded = append(old.Succeeded, &ReportItem{Name: "postgis", Version: "3.5.2"})
+	old.finish(nil)
+	failed := newReport("remove", 17, []string{"pg_cron"})
+	failed.finish(errors.New("apt-get failed"))
+
+	entries, err := LoadHistory(time.Time{})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("LoadHistory() = %d entries, %v, want 2", len(entries), err)
+	}
+	if entries[0].result() != "ok" || entries[1].result() != "failed" || entries[1].Error != "apt-get failed" {
+		t.Errorf("unexpected history entries: %+v %+v", entries[0], entries[1])
+	}
+	if entries, _ := LoadHistory(time.Now().Add(-time.Hour)); len(entries) != 1 || entries[0].Action != "remove" {
+		t.Errorf("LoadHistory(since 1h) should only return the remove entry")
+	}
+}
+
+func TestRollbackTargets(t *testing.T) {
+	item := []*ReportItem{{Name: "postgis"}}
+	entries := []*Report{
+		{ID: 1, Action: "install", Succeeded: item},
+		{ID: 2, Action: "update", Succeeded: item},
+		{ID: 3, Action: "remove", Error: "failed"},
+		{ID: 4, Action: "install", Succeeded: item},
+		{ID: 5, Action: "rollback", Succeeded: item, RolledBack: []int{4}},
+	}
+	ids := func(targets []*Report) []int {
+		var ids []int
+		for _, r := range targets {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+	if targets, err := rollbackTargets(entries, 0); err != nil || !reflect.DeepEqual(ids(targets), []int{2}) {
+		t.Errorf("rollbackTargets(0) = %v, %v, want [2]", ids(targets), err)
+	}
+	if targets, err := rollbackTargets(entries, 1); err != nil || !reflect.DeepEqual(ids(targets), []int{2, 1}) {
+		t.Errorf("rollbackTargets(1) = %v, %v, want [2 1]", ids(targets), err)
+	}
+	for _, to := range []int{3, 4, 9} {
+		if _, err := rollbackTargets(entries, to); err == nil {
+			t.Errorf("rollbackTargets(%d) should fail", to)
+		}
+	}
+}
+
+func TestMatchAnyPackage(t *testing.T) {
+	specs := []string{"postgis35_16*", "pg_cron_16=1.6*", "pgvector_16-0.8.0"}
+	for _, name := range []string{"postgis35_16-client", "pg_cron_16", "pgvector_16"} {
+		if !matchAnyPackage(specs, name) {
+			t.Errorf("matchAnyPackage(%q) = false, want true", name)
+		}
+	}
+	if matchAnyPackage(specs, "postgresql-16") {
+		t.Errorf("matchAnyPackage(postgresql-16) = true, want false")
+	}
+}
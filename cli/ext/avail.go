@@ -3,6 +3,7 @@ package ext
 import (
 	"fmt"
 	"pig/internal/config"
+	"sort"
 	"strconv"
 )
 
@@ -80,3 +81,22 @@ func (e *Extension) Available(pgVer int) bool {
 		return true
 	}
 }
+
+// SupportedPgVersions returns PostgreSQL major versions the extension is available for on current os, sorted desc
+func (e *Extension) SupportedPgVersions() []int {
+	vers := e.PgVer
+	switch config.OSType {
+	case config.DistroEL:
+		vers = e.RpmPg
+	case config.DistroDEB:
+		vers = e.DebPg
+	}
+	var majors []int
+	for _, v := range vers {
+		if major, err := strconv.Atoi(v); err == nil && e.Available(major) {
+			majors = append(majors, major)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(majors)))
+	return majors
+}
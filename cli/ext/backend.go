@@ -0,0 +1,417 @@
+package ext
+
+import (
+	"fmt"
+	"maps"
+	"os/exec"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PackageBackend is a package manager that installs extension packages, apt, dnf & yum are built in
+// downstream code could add its own with RegisterBackend and select it with Backend
+type PackageBackend interface {
+	// Resolve returns package names of a catalog package pattern for given pg version, pinned to version if not empty
+	Resolve(pattern string, version string, pgVer int) []string
+	// Install installs given packages (or local package files)
+	Install(pkgs []string, yes bool) error
+	// Remove removes given packages
+	Remove(pkgs []string, yes bool) error
+	// Update updates given packages to the latest version
+	Update(pkgs []string, yes bool) error
+	// Query returns installed versions of given packages, packages not installed are omitted
+	Query(pkgs []string) (map[string]string, error)
+}
+
+// optionChecker is implemented by backends that validate install options before installing
+type optionChecker interface {
+	CheckOptions() error
+}
+
+// Backend is the name of package backend to use, chosen by os type if empty
+var Backend string
+
+var backends = map[string]PackageBackend{}
+
+func init() {
+	RegisterBackend("apt", &aptBackend{})
+	RegisterBackend("dnf", &dnfBackend{bin: "dnf"})
+	RegisterBackend("yum", &dnfBackend{bin: "yum"})
+}
+
+// RegisterBackend registers a package backend with given name, an existing backend with same name is replaced
+func RegisterBackend(name string, b PackageBackend) {
+	backends[name] = b
+}
+
+// Backends returns names of registered package backends
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultBackend returns the name of built-in backend of current os, empty if there's none
+func DefaultBackend() string {
+	switch config.OSType {
+	case config.DistroEL:
+		if config.OSVersion == "8" || config.OSVersion == "9" {
+			return "dnf"
+		}
+		return "yum"
+	case config.DistroDEB:
+		return "apt"
+	}
+	return ""
+}
+
+// GetBackend returns the selected package backend
+func GetBackend() (PackageBackend, error) {
+	name := Backend
+	if name == "" {
+		if name = DefaultBackend(); name == "" {
+			return nil, fmt.Errorf("no package backend for os type %s, choose one of: %s", config.OSType, strings.Join(Backends(), ", "))
+		}
+	}
+	b, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown package backend %q, choose one of: %s", name, strings.Join(Backends(), ", "))
+	}
+	return b, nil
+}
+
+// CommandError is a failed package manager command with the tail of its stderr
+type CommandError struct {
+	Err    error
+	Stderr []string
+}
+
+func (e *CommandError) Error() string { return e.Err.Error() }
+func (e *CommandError) Unwrap() error { return e.Err }
+
+// runPackageCommand runs a package manager command with sudo, keeps stderr tail in returned error
+func runPackageCommand(args []string) error {
+	logrus.Infof("%s", strings.Join(args, " "))
+	if tail, err := utils.SudoCommandTail(args, stderrTailLines); err != nil {
+		return &CommandError{Err: err, Stderr: tail}
+	}
+	return nil
+}
+
+// recommendsArgs returns package manager args that skip recommended (weak) dependencies if ExcludeRecommends is set
+func recommendsArgs(osType string) []string {
+	if !ExcludeRecommends {
+		return nil
+	}
+	switch osType {
+	case config.DistroEL:
+		return []string{"--setopt=install_weak_deps=False"}
+	case config.DistroDEB:
+		return []string{"--no-install-recommends"}
+	}
+	return nil
+}
+
+// queryPackages runs a query command that prints "name\tversion" lines of installed packages
+func queryPackages(args []string) (map[string]string, error) {
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	versions := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if name, version, ok := strings.Cut(line, "\t"); ok && version != "" {
+			versions[name] = version
+		}
+	}
+	return versions, nil
+}
+
+// dnfBackend installs rpm packages with dnf or yum
+type dnfBackend struct {
+	bin string
+}
+
+func (b *dnfBackend) Resolve(pattern string, version string, pgVer int) []string {
+	pkgs := processPkgName(pattern, pgVer)
+	if version != "" {
+		for i, pkg := range pkgs {
+			pkgs[i] = fmt.Sprintf("%s-%s", pkg, version)
+		}
+	}
+	return pkgs
+}
+
+func (b *dnfBackend) Install(pkgs []string, yes bool) error {
+	args := b.installArgs()
+	if yes {
+		args = append(args, "-y")
+	}
+	return runPackageCommand(append(args, pkgs...))
+}
+
+// installArgs returns the dnf install command with repo, weak deps and signature options
+func (b *dnfBackend) installArgs() []string {
+	args := []string{b.bin, "install"}
+	if b.bin == "dnf" && JobsGiven {
+		args = append(args, fmt.Sprintf("--setopt=max_parallel_downloads=%d", max(Jobs, 1)))
+	}
+	for _, repo := range EnableRepos {
+		args = append(args, "--enablerepo="+repo)
+	}
+	for _, repo := range DisableRepos {
+		args = append(args, "--disablerepo="+repo)
+	}
+	args = append(args, repoURLArgs...)
+	args = append(args, recommendsArgs(config.DistroEL)...)
+	args = append(args, signatureArgs(config.DistroEL)...)
+	return args
+}
+
+func (b *dnfBackend) Remove(pkgs []string, yes bool) error {
+	// rpm removes unmodified config files anyway, modified ones are kept as .rpmsave
+	args := []string{b.bin, "remove"}
+	if yes {
+		args = append(args, "-y")
+	}
+	return runPackageCommand(append(args, pkgs...))
+}
+
+func (b *dnfBackend) Update(pkgs []string, yes bool) error {
+	args := []string{b.bin, "update"}
+	if yes {
+		args = append(args, "-y")
+	}
+	return runPackageCommand(append(args, pkgs...))
+}
+
+func (b *dnfBackend) InstallVersions(versions map[string]string, downgrade bool, yes bool) error {
+	args := []string{b.bin, "install"}
+	if downgrade {
+		args[1] = "downgrade"
+	}
+	if yes {
+		args = append(args, "-y")
+	}
+	for _, pkg := range slices.Sorted(maps.Keys(versions)) {
+		args = append(args, fmt.Sprintf("%s-%s", pkg, versions[pkg]))
+	}
+	return runPackageCommand(args)
+}
+
+func (b *dnfBackend) Query(pkgs []string) (map[string]string, error) {
+	return queryPackages(append([]string{"rpm", "-q", "--qf", "%{NAME}\t%{VERSION}-%{RELEASE}\n"}, pkgs...))
+}
+
+// aptBackend installs deb packages with apt-get
+type aptBackend struct{}
+
+func (b *aptBackend) Resolve(pattern string, version string, pgVer int) []string {
+	pkgs := processPkgName(pattern, pgVer)
+	if version != "" {
+		for i, pkg := range pkgs {
+			pkgs[i] = fmt.Sprintf("%s=%s*", pkg, version)
+		}
+	}
+	return pkgs
+}
+
+func (b *aptBackend) CheckOptions() error {
+	// apt can not toggle a single source, use the enabled repo as target release instead
+	if len(EnableRepos) > 1 {
+		return fmt.Errorf("apt accepts only one target release, got %s", strings.Join(EnableRepos, ", "))
+	}
+	if len(DisableRepos) > 0 {
+		logrus.Warnf("apt does not support disabling repo per install, ignore: %s", strings.Join(DisableRepos, ", "))
+	}
+	return nil
+}
+
+func (b *aptBackend) Install(pkgs []string, yes bool) error {
+	args := b.installArgs()
+	if yes {
+		args = append(args, "-y")
+	}
+	return runPackageCommand(append(args, pkgs...))
+}
+
+// installArgs returns the apt-get install command with target release, recommends and signature options
+func (b *aptBackend) installArgs() []string {
+	args := []string{"apt-get", "install"}
+	for _, repo := range EnableRepos {
+		args = append(args, "-t", repo)
+	}
+	args = append(args, recommendsArgs(config.DistroDEB)...)
+	args = append(args, signatureArgs(config.DistroDEB)...)
+	return args
+}
+
+func (b *aptBackend) Remove(pkgs []string, yes bool) error {
+	args := []string{"apt-get", "remove"}
+	if Purge {
+		args[1] = "purge"
+	}
+	if yes {
+		args = append(args, "-y")
+	}
+	return runPackageCommand(append(args, pkgs...))
+}
+
+func (b *aptBackend) Update(pkgs []string, yes bool) error {
+	args := []string{"apt-get", "upgrade"}
+	if yes {
+		args = append(args, "-y")
+	}
+	return runPackageCommand(append(args, pkgs...))
+}
+
+func (b *aptBackend) InstallVersions(versions map[string]string, downgrade bool, yes bool) error {
+	args := []string{"apt-get", "install"}
+	if downgrade {
+		args = append(args, "--allow-downgrades")
+	}
+	if yes {
+		args = append(args, "-y")
+	}
+	for _, pkg := range slices.Sorted(maps.Keys(versions)) {
+		args = append(args, fmt.Sprintf("%s=%s", pkg, versions[pkg]))
+	}
+	return runPackageCommand(args)
+}
+
+func (b *aptBackend) Query(pkgs []string) (map[string]string, error) {
+	return queryPackages(append([]string{"dpkg-query", "-W", "-f", "${Package}\t${Version}\n"}, pkgs...))
+}
+
+func (b *dnfBackend) FileOwned(path string) bool {
+	return exec.Command("rpm", "-qf", path).Run() == nil
+}
+
+func (b *aptBackend) FileOwned(path string) bool {
+	return exec.Command("dpkg-query", "-S", path).Run() == nil
+}
+
+func (b *dnfBackend) ConfigFiles(pkgs []string) ([]string, error) {
+	out, err := exec.Command("rpm", append([]string{"-qc"}, pkgs...)...).Output()
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "/") {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+func (b *aptBackend) ConfigFiles(pkgs []string) ([]string, error) {
+	out, err := exec.Command("dpkg-query", append([]string{"-W", "-f", "${Conffiles}\n"}, pkgs...)...).Output()
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return parseConffiles(string(out)), nil
+}
+
+// parseConffiles parses dpkg conffiles lines: " /etc/path md5sum [obsolete]"
+func parseConffiles(out string) []string {
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && strings.HasPrefix(fields[0], "/") {
+			files = append(files, fields[0])
+		}
+	}
+	return files
+}
+
+// removeSimulator is implemented by backends that could tell which packages a removal would take away
+type removeSimulator interface {
+	SimulateRemove(pkgs []string) ([]string, error)
+}
+
+func (b *dnfBackend) SimulateRemove(pkgs []string) ([]string, error) {
+	// dnf exits non-zero with --assumeno, the transaction is printed anyway
+	out, err := utils.SudoCommandOutput(append([]string{b.bin, "remove", "--assumeno"}, pkgs...))
+	if len(out) == 0 {
+		return nil, fmt.Errorf("failed to simulate removal: %v", err)
+	}
+	return parseDnfRemoval(string(out)), nil
+}
+
+func (b *aptBackend) SimulateRemove(pkgs []string) ([]string, error) {
+	out, err := utils.SudoCommandOutput(append([]string{"apt-get", "remove", "-s"}, pkgs...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate removal: %v", err)
+	}
+	return parseAptRemoval(string(out)), nil
+}
+
+// installSimulator is implemented by backends that could tell which packages an install would bring in
+type installSimulator interface {
+	SimulateInstall(pkgs []string) ([]string, error)
+}
+
+func (b *dnfBackend) SimulateInstall(pkgs []string) ([]string, error) {
+	// dnf exits non-zero with --assumeno, the transaction is printed anyway
+	out, err := utils.SudoCommandOutput(append(append(b.installArgs(), "--assumeno"), pkgs...))
+	if len(out) == 0 {
+		return nil, fmt.Errorf("failed to simulate install: %v", err)
+	}
+	return parseDnfTransaction(string(out), "Installing", "Upgrading"), nil
+}
+
+func (b *aptBackend) SimulateInstall(pkgs []string) ([]string, error) {
+	out, err := utils.SudoCommandOutput(append(append(b.installArgs(), "-s"), pkgs...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate install: %v", err)
+	}
+	return parseAptSimulation(string(out), "Inst"), nil
+}
+
+// parseAptRemoval parses "Remv <pkg> [version]" lines of apt-get remove -s output
+func parseAptRemoval(out string) []string {
+	return parseAptSimulation(out, "Remv")
+}
+
+// parseAptSimulation parses "<action> <pkg> [version]" lines of apt-get -s output
+func parseAptSimulation(out string, action string) []string {
+	var pkgs []string
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == action {
+			pkgs = append(pkgs, fields[1])
+		}
+	}
+	return pkgs
+}
+
+// parseDnfRemoval parses package rows in "Removing..." sections of dnf remove transaction
+func parseDnfRemoval(out string) []string {
+	return parseDnfTransaction(out, "Removing")
+}
+
+// parseDnfTransaction parses package rows in sections of dnf transaction starting with one of given titles
+func parseDnfTransaction(out string, titles ...string) []string {
+	var pkgs []string
+	inSection := false
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case line != "" && !strings.HasPrefix(line, " "):
+			inSection = slices.ContainsFunc(titles, func(t string) bool { return strings.HasPrefix(line, t) })
+		case line == "":
+			inSection = false
+		case inSection:
+			if fields := strings.Fields(line); len(fields) >= 4 {
+				pkgs = append(pkgs, fields[0])
+			}
+		}
+	}
+	return pkgs
+}
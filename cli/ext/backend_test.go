@@ -0,0 +1,187 @@
+package ext
+
+import (
+	"os"
+	"path/filepath"
+	"pig/internal/config"
+	"slices"
+	"testing"
+)
+
+// fakeBackend pins packages with @version, and does nothing else
+type fakeBackend struct{}
+
+func (b *fakeBackend) Resolve(pattern string, version string, pgVer int) []string {
+	pkgs := processPkgName(pattern, pgVer)
+	if version != "" {
+		for i, pkg := range pkgs {
+			pkgs[i] = pkg + "@" + version
+		}
+	}
+	return pkgs
+}
+func (b *fakeBackend) Install(pkgs []string, yes bool) error { return nil }
+func (b *fakeBackend) Remove(pkgs []string, yes bool) error  { return nil }
+func (b *fakeBackend) Update(pkgs []string, yes bool) error  { return nil }
+func (b *fakeBackend) Query(pkgs []string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func TestRegisterBackend(t *testing.T) {
+	savedBackend, savedOS := Backend, config.OSType
+	defer func() {
+		Backend, config.OSType = savedBackend, savedOS
+		delete(backends, "fake")
+	}()
+	config.OSType = config.DistroDEB
+
+	fake := &fakeBackend{}
+	RegisterBackend("fake", fake)
+	if !slices.Contains(Backends(), "fake") {
+		t.Fatalf("fake backend is not registered: %v", Backends())
+	}
+	Backend = "fake"
+	b, err := GetBackend()
+	if err != nil || b != fake {
+		t.Fatalf("GetBackend() = %v, %v, want the fake backend", b, err)
+	}
+	if got, want := b.Resolve("postgresql-$v-demo", "1.2", 17), []string{"postgresql-17-demo@1.2"}; !slices.Equal(got, want) {
+		t.Errorf("fake backend resolved %v, want %v", got, want)
+	}
+	Backend = "nothing"
+	if _, err := GetBackend(); err == nil {
+		t.Errorf("unknown backend should be rejected")
+	}
+	Backend = ""
+	if b, err := GetBackend(); err != nil || b != backends["apt"] {
+		t.Errorf("default backend on deb should be apt, got %v, %v", b, err)
+	}
+}
+
+func TestParseRemoval(t *testing.T) {
+	apt := "Reading package lists...\nRemv postgresql-16-cron [1.6.4-1]\nRemv postgresql-16 [16.4-1.pgdg120+1]\n"
+	if got := parseAptRemoval(apt); !slices.Equal(got, []string{"postgresql-16-cron", "postgresql-16"}) {
+		t.Errorf("parseAptRemoval() = %v", got)
+	}
+	dnf := `Dependencies resolved.
+================================================================================
+ Package              Arch       Version              Repository          Size
+================================================================================
+Removing:
+ pg_cron_16           x86_64     1.6.4-1PGDG.rhel9    @pgdg16            234 k
+Removing dependent packages:
+ postgresql16-server  x86_64     16.4-1PGDG.rhel9     @pgdg16             26 M
+
+Transaction Summary
+================================================================================
+Remove  2 Packages
+`
+	if got := parseDnfRemoval(dnf); !slices.Equal(got, []string{"pg_cron_16", "postgresql16-server"}) {
+		t.Errorf("parseDnfRemoval() = %v", got)
+	}
+	for pkg, server := range map[string]bool{"postgresql-16": true, "postgresql16-server": true, "postgresql-common": true, "postgresql-16-cron": false, "postgresql16-contrib": false} {
+		if serverPackageRe.MatchString(pkg) != server {
+			t.Errorf("server package match of %s should be %v", pkg, server)
+		}
+	}
+}
+
+func TestParseInstallSimulation(t *testing.T) {
+	apt := "Reading package lists...\nInst postgresql-16-postgis-3-scripts (3.5.0+dfsg-1.pgdg120+1 PostgreSQL for Debian)\nInst postgresql-16-postgis-3 (3.5.0+dfsg-1.pgdg120+1 PostgreSQL for Debian)\nConf postgresql-16-postgis-3 (3.5.0+dfsg-1.pgdg120+1 PostgreSQL for Debian)\n"
+	if got := parseAptSimulation(apt, "Inst"); !slices.Equal(got, []string{"postgresql-16-postgis-3-scripts", "postgresql-16-postgis-3"}) {
+		t.Errorf("parseAptSimulation() = %v", got)
+	}
+	dnf := `Dependencies resolved.
+================================================================================
+ Package              Arch       Version              Repository          Size
+================================================================================
+Installing:
+ postgis35_16         x86_64     3.5.0-1PGDG.rhel9    pgdg16             6.3 M
+Upgrading:
+ gdal-libs            x86_64     3.4.3-2.el9          epel               8.1 M
+Installing dependencies:
+ geos                 x86_64     3.12.2-1.el9         pigsty-infra       1.1 M
+
+Transaction Summary
+================================================================================
+Install  2 Packages
+`
+	if got := parseDnfTransaction(dnf, "Installing", "Upgrading"); !slices.Equal(got, []string{"postgis35_16", "gdal-libs", "geos"}) {
+		t.Errorf("parseDnfTransaction() = %v", got)
+	}
+}
+
+func TestKeptConfigFiles(t *testing.T) {
+	out := " /etc/pgbouncer/pgbouncer.ini 9e107d9d372bb6826bd81d3542a419d6\n /etc/logrotate.d/pgbouncer 0cc175b9c0f1b6a831c399e269772661 obsolete\n\n"
+	if got := parseConffiles(out); len(got) != 2 || got[0] != "/etc/pgbouncer/pgbouncer.ini" || got[1] != "/etc/logrotate.d/pgbouncer" {
+		t.Errorf("parseConffiles() = %v", got)
+	}
+	dir := t.TempDir()
+	kept, saved, gone := filepath.Join(dir, "a.conf"), filepath.Join(dir, "b.conf"), filepath.Join(dir, "c.conf")
+	for _, path := range []string{kept, saved + ".rpmsave"} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := keptConfigFiles([]string{kept, saved, gone})
+	if len(got) != 2 || got[0] != kept || got[1] != saved+".rpmsave" {
+		t.Errorf("keptConfigFiles() = %v", got)
+	}
+}
+
+// ownerBackend reports paths in owned as owned by an installed package
+type ownerBackend struct {
+	fakeBackend
+	owned map[string]bool
+}
+
+func (b *ownerBackend) FileOwned(path string) bool { return b.owned[path] }
+
+func TestLeftoverPathsSkipOwned(t *testing.T) {
+	extDir := filepath.Join(t.TempDir(), "extension")
+	control, script := filepath.Join(extDir, "pg_cron.control"), filepath.Join(extDir, "pg_cron--1.6.sql")
+	if err := os.MkdirAll(extDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{control, script} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backend := &ownerBackend{owned: map[string]bool{control: true}}
+	got := leftoverPaths(backend, &PostgresInstall{ExtPath: extDir}, []*Extension{{Name: "pg_cron"}})
+	if !slices.Equal(got, []string{script}) {
+		t.Errorf("leftoverPaths() = %v, want only %s", got, script)
+	}
+	if got := leftoverPaths(&fakeBackend{}, &PostgresInstall{ExtPath: extDir}, []*Extension{{Name: "pg_cron"}}); got != nil {
+		t.Errorf("leftoverPaths() without owner check = %v, want none", got)
+	}
+}
+
+func TestRecommendsArgs(t *testing.T) {
+	defer func(saved bool) { ExcludeRecommends = saved }(ExcludeRecommends)
+	ExcludeRecommends = false
+	if args := recommendsArgs(config.DistroDEB); args != nil {
+		t.Errorf("recommendsArgs() without --exclude-recommends = %v", args)
+	}
+	ExcludeRecommends = true
+	if args := recommendsArgs(config.DistroDEB); !slices.Equal(args, []string{"--no-install-recommends"}) {
+		t.Errorf("deb recommendsArgs() = %v", args)
+	}
+	if args := recommendsArgs(config.DistroEL); !slices.Equal(args, []string{"--setopt=install_weak_deps=False"}) {
+		t.Errorf("el recommendsArgs() = %v", args)
+	}
+}
+
+func TestDnfInstallArgsJobs(t *testing.T) {
+	defer func(jobs int, given bool) { Jobs, JobsGiven = jobs, given }(Jobs, JobsGiven)
+	b := &dnfBackend{bin: "dnf"}
+	Jobs, JobsGiven = 4, false
+	if args := b.installArgs(); slices.Contains(args, "--setopt=max_parallel_downloads=4") {
+		t.Errorf("installArgs() without --jobs = %v, should keep dnf's own setting", args)
+	}
+	Jobs, JobsGiven = 8, true
+	if args := b.installArgs(); !slices.Contains(args, "--setopt=max_parallel_downloads=8") {
+		t.Errorf("installArgs() with --jobs 8 = %v", args)
+	}
+}
@@ -0,0 +1,85 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"pig/internal/config"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// BundleFileName is the name of user defined bundle file under config dir
+const BundleFileName = "bundles.yml"
+
+// DefaultBundles are built-in named sets of extensions that are commonly installed together
+var DefaultBundles = map[string][]string{
+	"gis-stack":        {"postgis", "postgis_topology", "postgis_raster", "postgis_sfcgal", "pgrouting", "h3", "h3_postgis", "pointcloud", "pointcloud_postgis"},
+	"timeseries-stack": {"timescaledb", "pg_cron", "pg_partman", "periods", "temporal_tables"},
+	"rag-stack":        {"vector", "vectorscale", "pg_search", "pg_similarity"},
+	"olap-stack":       {"pg_duckdb", "pg_analytics", "pg_parquet"},
+	"monitor-stack":    {"pg_stat_statements", "pg_stat_kcache", "pg_qualstats", "pg_wait_sampling", "hypopg", "pg_hint_plan"},
+	"security-stack":   {"pgaudit", "pgsodium", "anon", "set_user"},
+}
+
+// Bundles returns all available bundles, user defined bundles in config dir override built-in ones
+func (ec *ExtensionCatalog) Bundles() map[string][]string {
+	if ec.bundles != nil {
+		return ec.bundles
+	}
+	bundles := make(map[string][]string, len(DefaultBundles))
+	for name, members := range DefaultBundles {
+		bundles[name] = members
+	}
+	if config.ConfigDir != "" {
+		if data, err := os.ReadFile(config.ConfigPath(BundleFileName)); err == nil {
+			var userBundles map[string][]string
+			if err := yaml.Unmarshal(data, &userBundles); err != nil {
+				logrus.Warnf("failed to parse bundle file %s: %v", config.ConfigPath(BundleFileName), err)
+			}
+			for name, members := range userBundles {
+				bundles[name] = members
+			}
+		}
+	}
+	ec.bundles = bundles
+	return bundles
+}
+
+// ExpandBundles replaces bundle names in args with their member extensions, other args are kept as is
+func (ec *ExtensionCatalog) ExpandBundles(names []string) []string {
+	bundles := ec.Bundles()
+	var result []string
+	for _, name := range names {
+		if _, isExt := ec.ExtNameMap[name]; !isExt {
+			if members, ok := bundles[name]; ok {
+				logrus.Infof("expand bundle %s: %s", name, strings.Join(members, ", "))
+				result = append(result, members...)
+				continue
+			}
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// PrintBundles prints available bundles and their members
+func PrintBundles() {
+	bundles := Catalog.Bundles()
+	names := make([]string, 0, len(bundles))
+	for name := range bundles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Bundle\tExtensions")
+	fmt.Fprintln(w, "------\t----------")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, strings.Join(bundles[name], ", "))
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows)\n\n", len(names))
+}
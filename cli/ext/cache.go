@@ -0,0 +1,239 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"pig/internal/config"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	CacheDir  string // package cache dir, ~/.cache/pig/packages by default
+	UseCache  bool   // reuse cached package files, and download missing ones into cache before install
+	Jobs      = 4    // concurrent package downloads
+	JobsGiven bool   // --jobs is given explicitly, so dnf downloads are parallelized with it too
+)
+
+// CheckJobs validates an explicit --jobs, which parallelizes downloads into package cache,
+// or dnf's own downloads, other package managers download serially without the cache
+func CheckJobs() error {
+	if Jobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1, got %d", Jobs)
+	}
+	if UseCache {
+		return nil
+	}
+	name := Backend
+	if name == "" {
+		name = DefaultBackend()
+	}
+	if name != "dnf" {
+		return fmt.Errorf("--jobs requires --download-only-if-missing with %s backend", name)
+	}
+	return nil
+}
+
+// CachePath returns the package cache directory
+func CachePath() string {
+	if CacheDir != "" {
+		return CacheDir
+	}
+	return config.CachePath("packages")
+}
+
+// cachedPackage is a package file in cache, with name, version and arch parsed from its file name
+type cachedPackage struct {
+	path    string
+	name    string
+	version string
+	arch    string
+}
+
+// parsePackageFile parses a deb (name_version_arch.deb) or rpm (name-version-release.arch.rpm) file name
+func parsePackageFile(file string) (*cachedPackage, bool) {
+	base := filepath.Base(file)
+	if strings.HasSuffix(base, ".deb") {
+		parts := strings.Split(strings.TrimSuffix(base, ".deb"), "_")
+		if len(parts) != 3 {
+			return nil, false
+		}
+		version := strings.ReplaceAll(parts[1], "%3a", ":")
+		return &cachedPackage{path: file, name: parts[0], version: version, arch: parts[2]}, true
+	}
+	if strings.HasSuffix(base, ".rpm") {
+		nvra := strings.TrimSuffix(base, ".rpm")
+		dot := strings.LastIndex(nvra, ".")
+		if dot < 0 {
+			return nil, false
+		}
+		nvr, arch := nvra[:dot], nvra[dot+1:]
+		rel := strings.LastIndex(nvr, "-")
+		if rel < 0 {
+			return nil, false
+		}
+		ver := strings.LastIndex(nvr[:rel], "-")
+		if ver < 0 {
+			return nil, false
+		}
+		return &cachedPackage{path: file, name: nvr[:ver], version: nvr[ver+1:], arch: arch}, true
+	}
+	return nil, false
+}
+
+// stripEpoch removes the epoch prefix of a version, package file names have no epoch
+func stripEpoch(version string) string {
+	if _, after, ok := strings.Cut(version, ":"); ok {
+		return after
+	}
+	return version
+}
+
+// matchSpec reports whether the cached package is the package spec: name, apt name=version*, or dnf name-version
+func (p *cachedPackage) matchSpec(spec string) bool {
+	if name, version, ok := strings.Cut(spec, "="); ok {
+		return p.name == name && strings.HasPrefix(p.version, stripEpoch(strings.TrimSuffix(version, "*")))
+	}
+	if ok, _ := path.Match(spec, p.name); ok || p.name == spec {
+		return true
+	}
+	version, ok := strings.CutPrefix(spec, p.name+"-")
+	return ok && (p.version == version || strings.HasPrefix(p.version, version+".") || strings.HasPrefix(p.version, version+"-"))
+}
+
+// findCached returns the cached file of the package spec for given arch, at version want if not empty
+// the newest one is returned if several versions match, empty string if there is none
+func findCached(dir, spec, arch, want string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var best *cachedPackage
+	for _, entry := range entries {
+		p, ok := parsePackageFile(filepath.Join(dir, entry.Name()))
+		if !ok || entry.IsDir() || !p.matchSpec(spec) {
+			continue
+		}
+		if p.arch != arch && p.arch != "all" && p.arch != "noarch" {
+			continue
+		}
+		if want != "" && p.version != stripEpoch(want) {
+			continue
+		}
+		if best == nil || compareVersion(p.version, best.version) > 0 {
+			best = p
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.path
+}
+
+// candidateVersion returns the newest version of the package in repos, empty if unknown or pinned in spec
+func candidateVersion(spec string) string {
+	if strings.Contains(spec, "=") || strings.ContainsAny(spec, "*?[") {
+		return ""
+	}
+	var latest string
+	for _, r := range queryRepoVersions(spec) {
+		if r.Package == spec && (latest == "" || compareVersion(stripEpoch(r.Version), stripEpoch(latest)) > 0) {
+			latest = r.Version
+		}
+	}
+	return latest
+}
+
+// cachePackages translates package specs to cached package files, missing or outdated ones are downloaded into cache first
+// a cached file is used only if its name, version and arch match, otherwise the spec is kept for the package manager
+func cachePackages(pkgs []string) []string {
+	dir := CachePath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logrus.Warnf("failed to create package cache dir %s: %v", dir, err)
+		return pkgs
+	}
+	arch := normalizeArch(config.OSArch)
+	wants := make(map[string]string, len(pkgs))
+	var missing []string
+	for _, pkg := range pkgs {
+		wants[pkg] = candidateVersion(pkg)
+		if file := findCached(dir, pkg, arch, wants[pkg]); file != "" {
+			logrus.Debugf("reuse cached package %s: %s", pkg, file)
+		} else {
+			missing = append(missing, pkg)
+		}
+	}
+	if len(missing) > 0 {
+		logrus.Infof("downloading %d packages into package cache %s with %d jobs", len(missing), dir, Jobs)
+		if failed := downloadPackages(dir, missing, arch, Jobs); len(failed) > 0 {
+			logrus.Warnf("failed to cache packages: %s", strings.Join(failed, ", "))
+		}
+	}
+	result := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		file := findCached(dir, pkg, arch, wants[pkg])
+		if file == "" {
+			logrus.Debugf("no cached package matches %s, install it from repo", pkg)
+			file = pkg
+		}
+		result = append(result, file)
+	}
+	return result
+}
+
+// CacheInfo prints the package cache location and usage
+func CacheInfo() error {
+	dir := CachePath()
+	files, size, err := listCache(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Cache Dir : %s\n", dir)
+	fmt.Printf("Packages  : %d\n", len(files))
+	fmt.Printf("Size      : %s\n", humanize.Bytes(uint64(size)))
+	return nil
+}
+
+// CacheClean removes all cached package files
+func CacheClean() error {
+	dir := CachePath()
+	files, size, err := listCache(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			return fmt.Errorf("failed to remove %s: %v", f, err)
+		}
+	}
+	logrus.Infof("removed %d cached packages (%s) from %s", len(files), humanize.Bytes(uint64(size)), dir)
+	return nil
+}
+
+// listCache returns cached package files and their total size
+func listCache(dir string) ([]string, int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read cache dir %s: %v", dir, err)
+	}
+	var files []string
+	var size int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".rpm") || strings.HasSuffix(name, ".deb")) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files, size, nil
+}
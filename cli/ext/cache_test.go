@@ -0,0 +1,70 @@
+package ext
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindCached(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"postgresql-16-cron_1.6.3-1.pgdg120+1_amd64.deb",
+		"postgresql-16-cron_1.6.4-1.pgdg120+1_amd64.deb",
+		"postgresql-16-cron_1.6.4-1.pgdg120+1_arm64.deb",
+		"postgresql-16-cron-extra_2.0-1_amd64.deb",
+		"pg_cron_16-1.6.4-1PGDG.rhel9.x86_64.rpm",
+		"pg_cron_16-llvmjit-1.6.4-1PGDG.rhel9.x86_64.rpm",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string {
+		if name == "" {
+			return ""
+		}
+		return filepath.Join(dir, name)
+	}
+	tests := []struct {
+		spec, arch, want, file string
+	}{
+		{"postgresql-16-cron", "amd64", "", "postgresql-16-cron_1.6.4-1.pgdg120+1_amd64.deb"},
+		{"postgresql-16-cron", "amd64", "1.6.3-1.pgdg120+1", "postgresql-16-cron_1.6.3-1.pgdg120+1_amd64.deb"},
+		{"postgresql-16-cron", "amd64", "1.6.5-1.pgdg120+1", ""},
+		{"postgresql-16-cron=1.6.3*", "amd64", "", "postgresql-16-cron_1.6.3-1.pgdg120+1_amd64.deb"},
+		{"postgresql-16-cron", "riscv64", "", ""},
+		{"pg_cron_16", "x86_64", "", "pg_cron_16-1.6.4-1PGDG.rhel9.x86_64.rpm"},
+		{"pg_cron_16-1.6.4", "x86_64", "", "pg_cron_16-1.6.4-1PGDG.rhel9.x86_64.rpm"},
+		{"pg_cron_16-1.6", "x86_64", "", "pg_cron_16-1.6.4-1PGDG.rhel9.x86_64.rpm"},
+		{"pg_cron_16-1.5", "x86_64", "", ""},
+	}
+	for _, tt := range tests {
+		if got := findCached(dir, tt.spec, tt.arch, tt.want); got != path(tt.file) {
+			t.Errorf("findCached(%s, %s, %q) = %s, want %s", tt.spec, tt.arch, tt.want, got, path(tt.file))
+		}
+	}
+}
+
+func TestCheckJobs(t *testing.T) {
+	savedJobs, savedCache, savedBackend := Jobs, UseCache, Backend
+	t.Cleanup(func() { Jobs, UseCache, Backend = savedJobs, savedCache, savedBackend })
+	tests := []struct {
+		jobs    int
+		cache   bool
+		backend string
+		ok      bool
+	}{
+		{8, true, "apt", true},
+		{8, false, "dnf", true},
+		{8, false, "apt", false},
+		{8, false, "yum", false},
+		{0, true, "apt", false},
+	}
+	for _, tt := range tests {
+		Jobs, UseCache, Backend = tt.jobs, tt.cache, tt.backend
+		if err := CheckJobs(); (err == nil) != tt.ok {
+			t.Errorf("CheckJobs(jobs=%d, cache=%v, %s) = %v, want ok=%v", tt.jobs, tt.cache, tt.backend, err, tt.ok)
+		}
+	}
+}
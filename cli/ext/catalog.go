@@ -5,10 +5,10 @@ import (
 	"encoding/csv"
 	"fmt"
 	"os"
-	"path/filepath"
 	"pig/internal/config"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	_ "embed"
@@ -28,9 +28,11 @@ type ExtensionCatalog struct {
 	ExtNameMap  map[string]*Extension
 	ExtAliasMap map[string]*Extension
 	Dependency  map[string][]string
+	ProvideMap  map[string][]*Extension // virtual name to provider extensions, in id order
 	ControlLess map[string]bool
 	DataPath    string
 	AliasMap    map[string]string
+	bundles     map[string][]string
 }
 
 // DefaultExtensionCatalog creates a new ExtensionCatalog with embedded data which (may) never fails
@@ -40,13 +42,56 @@ func DefaultExtensionCatalog() *ExtensionCatalog {
 	return ec
 }
 
+// LoadUserCatalog loads the catalog from pigsty.csv in config dir, nil is returned if there is no such file
+func LoadUserCatalog() (*ExtensionCatalog, error) {
+	if config.ConfigDir == "" {
+		return nil, nil
+	}
+	path := config.ConfigPath("pigsty.csv")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read catalog %s: %v", path, err)
+	}
+	ec := &ExtensionCatalog{}
+	if err := ec.Load(data); err != nil {
+		return nil, fmt.Errorf("failed to load catalog %s: %v", path, err)
+	}
+	ec.DataPath = path
+	return ec, nil
+}
+
+// SetCatalog replaces the global catalog, extensions of detected PostgreSQL installations are linked to the new one
+func SetCatalog(ec *ExtensionCatalog) {
+	Catalog = ec
+	linked := make(map[*PostgresInstall]bool)
+	pis := []*PostgresInstall{Active, Postgres}
+	for _, pi := range Installs {
+		pis = append(pis, pi)
+	}
+	for _, pi := range pis {
+		if pi == nil || linked[pi] {
+			continue
+		}
+		linked[pi] = true
+		for _, ei := range pi.Extensions {
+			name := ei.ControlName
+			if name == "" && ei.Extension != nil {
+				name = ei.Extension.Name // control-less extension
+			}
+			ei.Extension = ec.ExtNameMap[name]
+		}
+	}
+}
+
 // NewExtensionCatalog creates a new ExtensionCatalog, using embedded data if any error occurs
 func NewExtensionCatalog(paths ...string) (*ExtensionCatalog, error) {
 	ec := &ExtensionCatalog{}
 	var data []byte
 	var defaultCsvPath string
 	if config.ConfigDir != "" {
-		defaultCsvPath = filepath.Join(config.ConfigDir, "pigsty.csv")
+		defaultCsvPath = config.ConfigPath("pigsty.csv")
 		if !slices.Contains(paths, defaultCsvPath) {
 			paths = append(paths, defaultCsvPath)
 		}
@@ -112,6 +157,7 @@ func (ec *ExtensionCatalog) Load(data []byte) error {
 	ec.ExtNameMap = make(map[string]*Extension)
 	ec.ExtAliasMap = make(map[string]*Extension)
 	ec.Dependency = make(map[string][]string)
+	ec.ProvideMap = make(map[string][]*Extension)
 	for i := range extensions {
 		ext := &extensions[i]
 		ec.Extensions[i] = ext
@@ -119,6 +165,9 @@ func (ec *ExtensionCatalog) Load(data []byte) error {
 		if ext.Alias != "" && ext.Lead {
 			ec.ExtAliasMap[ext.Alias] = ext
 		}
+		for _, virtual := range ext.Provides {
+			ec.ProvideMap[virtual] = append(ec.ProvideMap[virtual], ext)
+		}
 		if len(ext.Requires) > 0 {
 			for _, req := range ext.Requires {
 				if _, exists := ec.Dependency[req]; !exists {
@@ -137,9 +186,85 @@ func (ec *ExtensionCatalog) Load(data []byte) error {
 		}
 	}
 	ec.ControlLess = ctrlLess
+	ec.loadConflicts()
 	return nil
 }
 
+// loadConflicts derives conflicting extensions from "conflict with <ext>" notes in comments
+// conflicts are symmetric, and unresolvable names are ignored
+func (ec *ExtensionCatalog) loadConflicts() {
+	addConflict := func(ext *Extension, name string) {
+		if ext.Name != name && !slices.Contains(ext.Conflicts, name) {
+			ext.Conflicts = append(ext.Conflicts, name)
+		}
+	}
+	for _, ext := range ec.Extensions {
+		for _, note := range strings.Split(strings.ToLower(ext.Comment), ",") {
+			_, target, found := strings.Cut(strings.TrimSpace(note), "conflict with ")
+			if !found || strings.HasPrefix(strings.TrimSpace(note), "no ") {
+				continue
+			}
+			words := strings.Fields(target)
+			if len(words) == 0 {
+				continue
+			}
+			// try the full phrase as a name first (citus columnar -> citus_columnar), then the first word
+			var other *Extension
+			for _, candidate := range []string{strings.Join(words, "_"), words[0]} {
+				if e, ok := ec.ExtNameMap[candidate]; ok {
+					other = e
+					break
+				}
+				if e, ok := ec.ExtAliasMap[candidate]; ok {
+					other = e
+					break
+				}
+			}
+			if other == nil {
+				continue
+			}
+			addConflict(ext, other.Name)
+			addConflict(other, ext.Name)
+		}
+	}
+}
+
+// Categories returns distinct extension categories in catalog order
+func (ec *ExtensionCatalog) Categories() []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, ext := range ec.Extensions {
+		if ext.Category != "" && !seen[ext.Category] {
+			seen[ext.Category] = true
+			categories = append(categories, ext.Category)
+		}
+	}
+	return categories
+}
+
+// PgMajors returns distinct PostgreSQL major versions known to the catalog, sorted asc
+func (ec *ExtensionCatalog) PgMajors() []int {
+	var majors []int
+	for _, ext := range ec.Extensions {
+		for _, v := range ext.PgVer {
+			if major, err := strconv.Atoi(v); err == nil && !slices.Contains(majors, major) {
+				majors = append(majors, major)
+			}
+		}
+	}
+	slices.Sort(majors)
+	return majors
+}
+
+// CheckPgMajor returns an error if given major version is not known to the catalog
+func (ec *ExtensionCatalog) CheckPgMajor(major int) error {
+	majors := ec.PgMajors()
+	if len(majors) == 0 || slices.Contains(majors, major) {
+		return nil
+	}
+	return fmt.Errorf("unknown PostgreSQL major version %d, catalog knows %d-%d", major, majors[0], majors[len(majors)-1])
+}
+
 // GetDependency returns the dependent extension with the given extensino name
 func GetDependency(name string) []string {
 	return Catalog.Dependency[name]
@@ -0,0 +1,161 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	CreateExtension bool   // run CREATE EXTENSION after install
+	CreateDatabase  string // database to create extensions in, psql default if empty
+	TargetSchema    string // schema to create relocatable extensions in
+	PostCreateSQL   string // sql file run in the same connection after extensions are created
+)
+
+// resolveExtensions resolves names, aliases and bundles into catalog extensions, unknown names are ignored
+func resolveExtensions(names []string) []*Extension {
+	var exts []*Extension
+	seen := make(map[string]bool)
+	for _, name := range Catalog.ExpandBundles(names) {
+		name, _, _ = strings.Cut(name, "=")
+		if ext, ok := lookupExtension(name); ok && !seen[ext.Name] {
+			seen[ext.Name] = true
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+// CheckTargetSchema validates --to-schema against given extensions, only relocatable extensions are allowed
+func CheckTargetSchema(names []string) error {
+	if TargetSchema == "" {
+		return nil
+	}
+	if !CreateExtension {
+		return fmt.Errorf("--to-schema must be used with --create")
+	}
+	var fixed []string
+	for _, ext := range resolveExtensions(names) {
+		if ext.NeedDDL && ext.Relocatable != "t" {
+			fixed = append(fixed, fmt.Sprintf("%s (%s)", ext.Name, ext.SchemaStr()))
+		}
+	}
+	if len(fixed) > 0 {
+		return fmt.Errorf("can not create non-relocatable extensions in schema %s: %s", TargetSchema, strings.Join(fixed, ", "))
+	}
+	return nil
+}
+
+// CreateSQLIn returns the CREATE EXTENSION statement, in given schema if not empty
+func (e *Extension) CreateSQLIn(schema string) string {
+	var buf strings.Builder
+	buf.WriteString("CREATE EXTENSION IF NOT EXISTS " + quoteIdent(e.Name))
+	if schema != "" {
+		buf.WriteString(" SCHEMA " + quoteIdent(schema))
+	}
+	if len(e.Requires) > 0 {
+		buf.WriteString(" CASCADE")
+	}
+	buf.WriteString(";")
+	return buf.String()
+}
+
+// CreateExtensions runs CREATE EXTENSION for given extensions that need ddl
+func CreateExtensions(names []string) error {
+	if !CreateExtension {
+		return nil
+	}
+	exts := resolveExtensions(names)
+	if DependencyOrder {
+		var err error
+		if exts, err = SortByDependency(exts); err != nil {
+			return err
+		}
+	}
+	var creates []*Extension
+	for _, ext := range exts {
+		if !ext.NeedDDL {
+			logrus.Debugf("extension %s does not need CREATE EXTENSION", ext.Name)
+			continue
+		}
+		creates = append(creates, ext)
+	}
+	if PostCreateSQL != "" {
+		return createWithPostSQL(creates)
+	}
+	var failed []string
+	for _, ext := range creates {
+		sql := ext.CreateSQLIn(TargetSchema)
+		logrus.Infof("%s", sql)
+		if _, err := PsqlQuery(CreateDatabase, sql); err != nil {
+			logrus.Errorf("failed to create extension %s: %v", ext.Name, err)
+			failed = append(failed, ext.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to create extensions: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// CheckPostCreateSQL validates --post-create-sql: it requires --create and a readable file
+func CheckPostCreateSQL() error {
+	if PostCreateSQL == "" {
+		return nil
+	}
+	if !CreateExtension {
+		return fmt.Errorf("--post-create-sql must be used with --create")
+	}
+	if _, err := os.ReadFile(PostCreateSQL); err != nil {
+		return fmt.Errorf("can not read post create sql file: %v", err)
+	}
+	return nil
+}
+
+// createWithPostSQL creates extensions then runs the post create sql file in one psql session,
+// each failed statement is reported with its file and line, and the remaining statements still run
+func createWithPostSQL(exts []*Extension) error {
+	path, err := filepath.Abs(PostCreateSQL)
+	if err != nil {
+		return err
+	}
+	var script strings.Builder
+	for _, ext := range exts {
+		sql := ext.CreateSQLIn(TargetSchema)
+		logrus.Infof("%s", sql)
+		script.WriteString(sql + "\n")
+	}
+	logrus.Infof("running %s in database %s", path, CreateDatabase)
+	script.WriteString(`\i '` + strings.ReplaceAll(path, `'`, `\'`) + "'\n")
+	errs, err := PsqlScript(CreateDatabase, script.String())
+	if err != nil {
+		return err
+	}
+	var failed []string
+	sqlErrors := 0
+	for _, e := range errs {
+		if e.File == psqlStdin && e.Line >= 1 && e.Line <= len(exts) {
+			logrus.Errorf("failed to create extension %s: %s", exts[e.Line-1].Name, e.Message)
+			failed = append(failed, exts[e.Line-1].Name)
+			continue
+		}
+		sqlErrors++
+		logrus.Errorf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	switch {
+	case len(failed) > 0:
+		return fmt.Errorf("failed to create extensions: %s", strings.Join(failed, ", "))
+	case sqlErrors > 0:
+		return fmt.Errorf("%d statements failed in %s", sqlErrors, PostCreateSQL)
+	}
+	return nil
+}
+
+// quoteIdent quotes a sql identifier
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
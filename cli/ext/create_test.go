@@ -0,0 +1,52 @@
+package ext
+
+import "testing"
+
+func TestCreateSQLIn(t *testing.T) {
+	tests := []struct {
+		ext    *Extension
+		schema string
+		want   string
+	}{
+		{&Extension{Name: "vector"}, "", `CREATE EXTENSION IF NOT EXISTS "vector";`},
+		{&Extension{Name: "vector"}, "ext", `CREATE EXTENSION IF NOT EXISTS "vector" SCHEMA "ext";`},
+		{&Extension{Name: "postgis_raster", Requires: []string{"postgis"}}, `we"ird`, `CREATE EXTENSION IF NOT EXISTS "postgis_raster" SCHEMA "we""ird" CASCADE;`},
+	}
+	for _, tt := range tests {
+		if got := tt.ext.CreateSQLIn(tt.schema); got != tt.want {
+			t.Errorf("CreateSQLIn(%q) = %s, want %s", tt.schema, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTargetSchema(t *testing.T) {
+	defer func() { CreateExtension, TargetSchema = false, "" }()
+	CreateExtension, TargetSchema = true, "ext"
+	if err := CheckTargetSchema([]string{"vector"}); err != nil {
+		t.Errorf("vector is relocatable, got error: %v", err)
+	}
+	if err := CheckTargetSchema([]string{"pg_cron"}); err == nil {
+		t.Errorf("pg_cron is not relocatable, expect error")
+	}
+	CreateExtension = false
+	if err := CheckTargetSchema([]string{"vector"}); err == nil {
+		t.Errorf("--to-schema without --create should fail")
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	stderr := `psql:<stdin>:2: ERROR:  extension "bogus" is not available
+psql:/tmp/post.sql:7: ERROR:  syntax error at or near "FAIL"
+psql:/tmp/post.sql:9: NOTICE:  relation "cfg" already exists, skipping
+`
+	errs := parseScriptErrors(stderr)
+	if len(errs) != 2 {
+		t.Fatalf("parseScriptErrors() returned %d errors, want 2", len(errs))
+	}
+	if errs[0].File != psqlStdin || errs[0].Line != 2 || errs[0].Message != `extension "bogus" is not available` {
+		t.Errorf("errs[0] = %+v", errs[0])
+	}
+	if errs[1].File != "/tmp/post.sql" || errs[1].Line != 7 {
+		t.Errorf("errs[1] = %+v", errs[1])
+	}
+}
@@ -0,0 +1,14 @@
+package ext
+
+// ExtensionExists returns the canonical name of an extension found by name, alias or provided name
+// if installed is set, the extension must also be installed on the designated PostgreSQL
+func ExtensionExists(name string, installed bool) (string, bool) {
+	ext, ok := lookupExtension(name)
+	if !ok {
+		return "", false
+	}
+	if installed && (Postgres == nil || Postgres.ExtensionMap[ext.Name] == nil) {
+		return ext.Name, false
+	}
+	return ext.Name, true
+}
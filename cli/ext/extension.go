@@ -4,7 +4,9 @@ import (
 	"bytes"
 	_ "embed"
 	"fmt"
+	"maps"
 	"pig/internal/config"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,40 +18,48 @@ import (
 
 // Extension represents a PostgreSQL extension record
 type Extension struct {
-	ID          int      `csv:"id"`          // Primary key
-	Name        string   `csv:"name"`        // Extension name
-	Alias       string   `csv:"alias"`       // Alternative name
-	Category    string   `csv:"category"`    // Extension category
-	URL         string   `csv:"url"`         // Project URL
-	License     string   `csv:"license"`     // License type
-	Tags        []string `csv:"tags"`        // Extension tags
-	Version     string   `csv:"version"`     // Extension version
-	Repo        string   `csv:"repo"`        // Repository name
-	Lang        string   `csv:"lang"`        // Programming language
-	Utility     bool     `csv:"utility"`     // Is utility extension
-	Lead        bool     `csv:"lead"`        // Is lead extension
-	HasSolib    bool     `csv:"has_solib"`   // Has shared library
-	NeedDDL     bool     `csv:"need_ddl"`    // Needs DDL changes
-	NeedLoad    bool     `csv:"need_load"`   // Needs loading
-	Trusted     string   `csv:"trusted"`     // Is trusted extension
-	Relocatable string   `csv:"relocatable"` // Is relocatable
-	Schemas     []string `csv:"schemas"`     // Target schemas
-	PgVer       []string `csv:"pg_ver"`      // Supported PG versions
-	Requires    []string `csv:"requires"`    // Required extensions
-	RpmVer      string   `csv:"rpm_ver"`     // RPM version
-	RpmRepo     string   `csv:"rpm_repo"`    // RPM repository
-	RpmPkg      string   `csv:"rpm_pkg"`     // RPM package name
-	RpmPg       []string `csv:"rpm_pg"`      // RPM PG versions
-	RpmDeps     []string `csv:"rpm_deps"`    // RPM dependencies
-	DebVer      string   `csv:"deb_ver"`     // DEB version
-	DebRepo     string   `csv:"deb_repo"`    // DEB repository
-	DebPkg      string   `csv:"deb_pkg"`     // DEB package name
-	DebDeps     []string `csv:"deb_deps"`    // DEB dependencies
-	DebPg       []string `csv:"deb_pg"`      // DEB PG versions
-	BadCase     []string `csv:"bad_case"`    // Distro BadCase
-	EnDesc      string   `csv:"en_desc"`     // English description
-	ZhDesc      string   `csv:"zh_desc"`     // Chinese description
-	Comment     string   `csv:"comment"`     // Additional comments
+	ID          int            `csv:"id" json:"id"`                             // Primary key
+	Name        string         `csv:"name" json:"name"`                         // Extension name
+	Alias       string         `csv:"alias" json:"alias"`                       // Alternative name
+	Category    string         `csv:"category" json:"category"`                 // Extension category
+	URL         string         `csv:"url" json:"url"`                           // Project URL
+	License     string         `csv:"license" json:"license"`                   // License type
+	Tags        []string       `csv:"tags" json:"tags"`                         // Extension tags
+	Version     string         `csv:"version" json:"version"`                   // Extension version
+	Repo        string         `csv:"repo" json:"repo"`                         // Repository name
+	Lang        string         `csv:"lang" json:"lang"`                         // Programming language
+	Utility     bool           `csv:"utility" json:"utility"`                   // Is utility extension
+	Lead        bool           `csv:"lead" json:"lead"`                         // Is lead extension
+	HasSolib    bool           `csv:"has_solib" json:"has_solib"`               // Has shared library
+	NeedDDL     bool           `csv:"need_ddl" json:"need_ddl"`                 // Needs DDL changes
+	NeedLoad    bool           `csv:"need_load" json:"need_load"`               // Needs loading
+	Trusted     string         `csv:"trusted" json:"trusted"`                   // Is trusted extension
+	Relocatable string         `csv:"relocatable" json:"relocatable"`           // Is relocatable
+	Schemas     []string       `csv:"schemas" json:"schemas"`                   // Target schemas
+	PgVer       []string       `csv:"pg_ver" json:"pg_ver"`                     // Supported PG versions
+	Requires    []string       `csv:"requires" json:"requires"`                 // Required extensions
+	RpmVer      string         `csv:"rpm_ver" json:"rpm_ver"`                   // RPM version
+	RpmRepo     string         `csv:"rpm_repo" json:"rpm_repo"`                 // RPM repository
+	RpmPkg      string         `csv:"rpm_pkg" json:"rpm_pkg"`                   // RPM package name
+	RpmPg       []string       `csv:"rpm_pg" json:"rpm_pg"`                     // RPM PG versions
+	RpmDeps     []string       `csv:"rpm_deps" json:"rpm_deps"`                 // RPM dependencies
+	DebVer      string         `csv:"deb_ver" json:"deb_ver"`                   // DEB version
+	DebRepo     string         `csv:"deb_repo" json:"deb_repo"`                 // DEB repository
+	DebPkg      string         `csv:"deb_pkg" json:"deb_pkg"`                   // DEB package name
+	DebDeps     []string       `csv:"deb_deps" json:"deb_deps"`                 // DEB dependencies
+	DebPg       []string       `csv:"deb_pg" json:"deb_pg"`                     // DEB PG versions
+	BadCase     []string       `csv:"bad_case" json:"bad_case"`                 // Distro BadCase
+	EnDesc      string         `csv:"en_desc" json:"en_desc"`                   // English description
+	ZhDesc      string         `csv:"zh_desc" json:"zh_desc"`                   // Chinese description
+	Comment     string         `csv:"comment" json:"comment"`                   // Additional comments
+	Conflicts   []string       `csv:"-" json:"conflicts,omitempty"`             // Conflicting extensions (derived from comment)
+	Config      []string       `csv:"config" json:"config,omitempty"`           // Required postgresql.conf settings (optional column)
+	Source      string         `csv:"source" json:"source,omitempty"`           // Source repository URL (optional column)
+	Provides    []string       `csv:"provides" json:"provides,omitempty"`       // Virtual names provided by this extension (optional column)
+	Deprecated  bool           `csv:"deprecated" json:"deprecated,omitempty"`   // Abandoned or superseded (optional column)
+	Replacement string         `csv:"replacement" json:"replacement,omitempty"` // Maintained alternative of a deprecated extension (optional column)
+	RpmPgVer    map[int]string `csv:"rpm_pg_ver" json:"rpm_pg_ver,omitempty"`   // RPM version overrides by PG major (optional column)
+	DebPgVer    map[int]string `csv:"deb_pg_ver" json:"deb_pg_ver,omitempty"`   // DEB version overrides by PG major (optional column)
 }
 
 // SummaryURL returns the URL to the ext.pigsty.io catalog summary page
@@ -57,6 +67,66 @@ func (e *Extension) SummaryURL() string {
 	return fmt.Sprintf("https://ext.pigsty.io/#/%s", e.Name)
 }
 
+// SourceURL returns the source repository URL, empty if unknown or same as the website
+func (e *Extension) SourceURL() string {
+	source := e.Source
+	if source == "" && e.Repo == "CONTRIB" {
+		source = "https://github.com/postgres/postgres"
+	}
+	if strings.TrimSuffix(source, "/") == strings.TrimSuffix(e.URL, "/") {
+		return ""
+	}
+	return source
+}
+
+// DeprecatedNote returns the deprecation warning of the extension, empty if not deprecated
+func (e *Extension) DeprecatedNote() string {
+	if !e.Deprecated {
+		return ""
+	}
+	if e.Replacement != "" {
+		return fmt.Sprintf("⚠ Deprecated — use %s instead", e.Replacement)
+	}
+	return "⚠ Deprecated — no longer maintained"
+}
+
+// ListDesc returns the description shown in list tables, marked if the extension is deprecated
+func (e *Extension) ListDesc() string {
+	if !e.Deprecated {
+		return e.EnDesc
+	}
+	if e.Replacement != "" {
+		return fmt.Sprintf("[deprecated, use %s] %s", e.Replacement, e.EnDesc)
+	}
+	return "[deprecated] " + e.EnDesc
+}
+
+// SourceOnly tells whether the extension has no binary package on current platform and must be built from source
+func (e *Extension) SourceOnly() bool {
+	return e.RepoName() == ""
+}
+
+// BuildSource returns where to get the source to build the extension: source repository, or website
+func (e *Extension) BuildSource() string {
+	if source := e.SourceURL(); source != "" {
+		return source
+	}
+	return e.URL
+}
+
+// BuildRequires returns extensions required to build and use the extension from source, or "none"
+func (e *Extension) BuildRequires() string {
+	if len(e.Requires) == 0 {
+		return "none"
+	}
+	return strings.Join(e.Requires, ", ")
+}
+
+// NoPackageNote returns the note that there's no binary package of the extension on current platform
+func (e *Extension) NoPackageNote() string {
+	return fmt.Sprintf("No binary package for %s.%s, build and install it from source", config.OSCode, config.OSArch)
+}
+
 func (e *Extension) FullTextSearchSummary() string {
 	var buf bytes.Buffer
 	buf.WriteString(e.Name)
@@ -183,6 +253,58 @@ func (e *Extension) RepoName() string {
 	return ""
 }
 
+// PackageVersion returns the package version on current OS, fallback to extension version
+func (e *Extension) PackageVersion() string {
+	switch config.OSType {
+	case config.DistroEL:
+		if e.RpmVer != "" {
+			return e.RpmVer
+		}
+	case config.DistroDEB:
+		if e.DebVer != "" {
+			return e.DebVer
+		}
+	}
+	return e.Version
+}
+
+// PgPackageVersion returns the package version on current OS for given PG major, which may lag behind the latest
+func (e *Extension) PgPackageVersion(pgVer int) string {
+	var overrides map[int]string
+	switch config.OSType {
+	case config.DistroEL:
+		overrides = e.RpmPgVer
+	case config.DistroDEB:
+		overrides = e.DebPgVer
+	}
+	if v, ok := overrides[pgVer]; ok {
+		return v
+	}
+	return e.PackageVersion()
+}
+
+// RpmVersions returns the rpm version with per PG major overrides, e.g. 3.5.0 (pg12: 3.3.7)
+func (e *Extension) RpmVersions() string {
+	return formatPgVersions(e.RpmVer, e.RpmPgVer)
+}
+
+// DebVersions returns the deb version with per PG major overrides, e.g. 3.5.0 (pg12: 3.3.7)
+func (e *Extension) DebVersions() string {
+	return formatPgVersions(e.DebVer, e.DebPgVer)
+}
+
+// formatPgVersions formats a version with its per PG major overrides, newer PG majors first
+func formatPgVersions(version string, overrides map[int]string) string {
+	if len(overrides) == 0 {
+		return version
+	}
+	var items []string
+	for _, pgVer := range slices.Backward(slices.Sorted(maps.Keys(overrides))) {
+		items = append(items, fmt.Sprintf("pg%d: %s", pgVer, overrides[pgVer]))
+	}
+	return fmt.Sprintf("%s (%s)", version, strings.Join(items, ", "))
+}
+
 func (e *Extension) CreateSQL() string {
 	if len(e.Requires) > 0 {
 		return fmt.Sprintf("CREATE EXTENSION %s CASCADE;", e.Name)
@@ -201,14 +323,37 @@ func (e *Extension) SharedLib() string {
 	return "no shared library"
 }
 
-func (e *Extension) SuperUser() string {
-	if e.Trusted == "t" {
-		return "TRUST   :  Yes │  does not require superuser to install"
+// ConfigLines returns required postgresql.conf changes, derived from shared library if not provided by catalog
+func (e *Extension) ConfigLines() []string {
+	if len(e.Config) > 0 {
+		return e.Config
+	}
+	if e.NeedLoad {
+		return []string{fmt.Sprintf("shared_preload_libraries = '%s'", e.Name)}
+	}
+	return nil
+}
+
+// SuperUserNote explains whether database superuser is required to create the extension
+func (e *Extension) SuperUserNote() string {
+	switch e.Trusted {
+	case "t":
+		return "superuser is not required to create"
+	case "f":
+		return "require database superuser to create"
 	}
-	if e.Trusted == "f" {
-		return "TRUST   :  No  │  require database superuser to install"
+	return "unknown, may require dbsu to create"
+}
+
+// TrustedNote explains what the trusted flag of the extension means
+func (e *Extension) TrustedNote() string {
+	switch e.Trusted {
+	case "t":
+		return "non-superuser with CREATE on db can create it (PG13+)"
+	case "f":
+		return "untrusted, only superuser can create it"
 	}
-	return "TRUST   :  N/A │ unknown, may require dbsu to install"
+	return "trusted flag is unknown"
 }
 
 func (e *Extension) SchemaStr() string {
@@ -256,6 +401,13 @@ func (e *Extension) GetBool(name string) string {
 			return "No"
 		}
 		return "N/A"
+	case "superuser":
+		if e.Trusted == "t" {
+			return "No"
+		} else if e.Trusted == "f" {
+			return "Yes"
+		}
+		return "N/A"
 	}
 	return "N/A"
 }
@@ -317,6 +469,35 @@ func (e *Extension) GetStatus(ver int) string {
 	}
 }
 
+// RequiresStatus returns required extensions, marked with ✓ if it resolves to a package on current platform, or ✗ if not
+func (e *Extension) RequiresStatus() string {
+	pgVer := PostgresLatestMajorVersion
+	if Postgres != nil {
+		pgVer = Postgres.MajorVersion
+	}
+	items := make([]string, 0, len(e.Requires))
+	for _, name := range e.Requires {
+		mark := "✗"
+		if requireAvailable(name, pgVer) {
+			mark = "✓"
+		}
+		items = append(items, name+" "+mark)
+	}
+	return strings.Join(items, ", ")
+}
+
+// requireAvailable tells whether a required extension is installed, or has a package for given pg version
+func requireAvailable(name string, pgVer int) bool {
+	if Postgres != nil && Postgres.MajorVersion == pgVer && Postgres.ExtensionMap[name] != nil {
+		return true
+	}
+	dep, ok := lookupExtension(name)
+	if !ok {
+		return false
+	}
+	return dep.PackageName(pgVer) != "" && dep.Available(pgVer)
+}
+
 // NeedBy returns the list of extensions that depend on this extension
 // This function depends on the global Catalog.DependsMap
 func (e *Extension) DependsOn() []string {
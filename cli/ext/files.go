@@ -0,0 +1,127 @@
+package ext
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"pig/internal/config"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// file groups of package files, in print order
+var fileGroups = []string{"Libraries", "SQL", "Docs", "Other"}
+
+// PackageFiles is the file list of a package, grouped by kind
+type PackageFiles struct {
+	Package   string
+	Installed bool                // whether the list comes from installed package database
+	Groups    map[string][]string // group name to files
+}
+
+// listPackageFiles lists files of a package, from package database if installed, or from repo metadata otherwise
+func listPackageFiles(pkg string) (files []string, installed bool, err error) {
+	var local, remote []string
+	switch config.OSType {
+	case config.DistroEL:
+		local = []string{"rpm", "-ql", pkg}
+		remote = []string{"dnf", "repoquery", "-q", "-l", pkg}
+	case config.DistroDEB:
+		local = []string{"dpkg", "-L", pkg}
+		remote = []string{"apt-file", "list", "-x", "^" + regexp.QuoteMeta(pkg) + "$"} // apt-file matches the regex against package name
+	default:
+		return nil, false, fmt.Errorf("unsupported OS type: %s", config.OSType)
+	}
+	if out, err := exec.Command(local[0], local[1:]...).Output(); err == nil {
+		return parseFileList(string(out)), true, nil
+	}
+	if _, err := exec.LookPath(remote[0]); err != nil {
+		return nil, false, fmt.Errorf("package %s is not installed, and %s is not available to query repo metadata", pkg, remote[0])
+	}
+	out, err := exec.Command(remote[0], remote[1:]...).Output()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query files of %s with %s: %v", pkg, remote[0], err)
+	}
+	return parseFileList(string(out)), false, nil
+}
+
+// parseFileList parses file list output, strips "pkg: " prefix of apt-file and drops directory entries
+func parseFileList(out string) []string {
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if _, after, ok := strings.Cut(line, ": "); ok {
+			line = after
+		}
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "/") && line != "/." {
+			files = append(files, line)
+		}
+	}
+	sort.Strings(files)
+	var result []string
+	for i, f := range files {
+		if i+1 < len(files) && strings.HasPrefix(files[i+1], f+"/") {
+			continue // directory
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// fileGroup returns the group of given file path
+func fileGroup(path string) string {
+	ext := filepath.Ext(path)
+	switch {
+	case strings.Contains(path, "/doc/") || strings.Contains(path, "/man/") || strings.Contains(path, "/licenses/"):
+		return "Docs"
+	case ext == ".so" || strings.Contains(path, ".so.") || ext == ".bc" || strings.Contains(path, "/bitcode/"):
+		return "Libraries"
+	case ext == ".sql" || ext == ".control":
+		return "SQL"
+	}
+	return "Other"
+}
+
+// PackageFiles returns grouped file lists of the extension packages for given pg version
+// packages whose file list is unavailable are skipped with a warning
+func (e *Extension) PackageFiles(pgVer int) []*PackageFiles {
+	if pgVer == 0 {
+		pgVer = PostgresLatestMajorVersion
+	}
+	var result []*PackageFiles
+	for _, pkg := range processPkgName(e.PackageName(pgVer), pgVer) {
+		files, installed, err := listPackageFiles(pkg)
+		if err != nil {
+			logrus.Warnf("skip file list of %s: %v", pkg, err)
+			continue
+		}
+		pf := &PackageFiles{Package: pkg, Installed: installed, Groups: make(map[string][]string)}
+		for _, f := range files {
+			group := fileGroup(f)
+			pf.Groups[group] = append(pf.Groups[group], f)
+		}
+		result = append(result, pf)
+	}
+	return result
+}
+
+// Print prints the grouped file list
+func (pf *PackageFiles) Print() {
+	source := "repo metadata"
+	if pf.Installed {
+		source = "installed"
+	}
+	fmt.Printf("%s (%s)\n", pf.Package, source)
+	for _, group := range fileGroups {
+		files := pf.Groups[group]
+		if len(files) == 0 {
+			continue
+		}
+		fmt.Printf("  %s (%d):\n", group, len(files))
+		for _, f := range files {
+			fmt.Printf("    %s\n", f)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package ext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFileList(t *testing.T) {
+	out := "/.\n/usr\n/usr/lib/postgresql/16/lib\n/usr/lib/postgresql/16/lib/pg_cron.so\npostgresql-16-cron: /usr/share/doc/postgresql-16-cron/copyright\n"
+	want := []string{"/usr/lib/postgresql/16/lib/pg_cron.so", "/usr/share/doc/postgresql-16-cron/copyright"}
+	if got := parseFileList(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFileList() = %v, want %v", got, want)
+	}
+}
+
+func TestFileGroup(t *testing.T) {
+	tests := map[string]string{
+		"/usr/lib/postgresql/16/lib/pg_cron.so":                          "Libraries",
+		"/usr/lib/postgresql/16/lib/bitcode/pg_cron/src/job_metadata.bc": "Libraries",
+		"/usr/share/postgresql/16/extension/pg_cron.control":             "SQL",
+		"/usr/share/postgresql/16/extension/pg_cron--1.0.sql":            "SQL",
+		"/usr/share/doc/postgresql-16-cron/copyright":                    "Docs",
+		"/usr/pgsql-16/doc/extension/README.md":                          "Docs",
+		"/usr/lib/postgresql/16/bin/pgbench":                             "Other",
+	}
+	for path, want := range tests {
+		if got := fileGroup(path); got != want {
+			t.Errorf("fileGroup(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"pig/internal/config"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// VersionRecord is an available version of an extension package for a PostgreSQL major version
+type VersionRecord struct {
+	PgVer   int    `json:"pg_ver"`
+	Package string `json:"package"`
+	Version string `json:"version"`
+	Source  string `json:"source"`
+}
+
+// VersionHistory returns available versions of the extension across PostgreSQL majors
+// the catalog version is always included, and versions available in repos are queried from package manager
+func (e *Extension) VersionHistory() []*VersionRecord {
+	catalogVer, avail := e.RpmVer, e.RpmPg
+	if config.OSType == config.DistroDEB {
+		catalogVer, avail = e.DebVer, e.DebPg
+	}
+	var records []*VersionRecord
+	for _, ver := range avail {
+		pgVer, err := strconv.Atoi(ver)
+		if err != nil {
+			continue
+		}
+		pkgs := processPkgName(e.PackageName(pgVer), pgVer)
+		if len(pkgs) == 0 {
+			continue
+		}
+		pkg := pkgs[0] // the main package
+		seen := make(map[string]bool)
+		for _, r := range queryRepoVersions(pkg) {
+			if !seen[r.Version] {
+				seen[r.Version] = true
+				r.PgVer = pgVer
+				records = append(records, r)
+			}
+		}
+		if len(seen) == 0 && catalogVer != "" {
+			records = append(records, &VersionRecord{PgVer: pgVer, Package: pkg, Version: e.PgPackageVersion(pgVer), Source: "catalog"})
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].PgVer != records[j].PgVer {
+			return records[i].PgVer > records[j].PgVer
+		}
+		return compareVersion(records[i].Version, records[j].Version) < 0
+	})
+	return records
+}
+
+// PrintHistory prints available versions of the extension in a table
+func (e *Extension) PrintHistory() {
+	records := e.VersionHistory()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\n%s version history:\n", e.Name)
+	fmt.Fprintln(w, "PG\tPackage\tVersion\tSource")
+	fmt.Fprintln(w, "--\t-------\t-------\t------")
+	for _, r := range records {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.PgVer, r.Package, r.Version, r.Source)
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows)\n\n", len(records))
+}
+
+// queryRepoVersions lists all versions of a package available in repos (apt-cache madison / dnf --showduplicates)
+func queryRepoVersions(pkg string) []*VersionRecord {
+	var cmd *exec.Cmd
+	switch config.OSType {
+	case config.DistroEL:
+		cmd = exec.Command("dnf", "list", "--showduplicates", "-q", pkg)
+	case config.DistroDEB:
+		cmd = exec.Command("apt-cache", "madison", strings.TrimSuffix(pkg, "*"))
+	default:
+		return nil
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		logrus.Debugf("failed to query versions of %s: %v", pkg, err)
+		return nil
+	}
+	var records []*VersionRecord
+	for _, line := range strings.Split(string(output), "\n") {
+		if config.OSType == config.DistroDEB {
+			parts := strings.Split(line, "|")
+			if len(parts) < 3 {
+				continue
+			}
+			source := strings.Fields(parts[2])
+			if len(source) > 1 {
+				source = source[:2]
+			}
+			records = append(records, &VersionRecord{Package: strings.TrimSpace(parts[0]), Version: strings.TrimSpace(parts[1]), Source: strings.Join(source, " ")})
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) != 3 || !strings.Contains(fields[0], ".") {
+				continue // skip "Available Packages" headers
+			}
+			name := fields[0][:strings.LastIndex(fields[0], ".")]
+			records = append(records, &VersionRecord{Package: name, Version: fields[1], Source: fields[2]})
+		}
+	}
+	return records
+}
+
+// compareVersion compares two version strings by numeric and non-numeric segments
+func compareVersion(a, b string) int {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	}
+	as, bs := split(a), split(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		ai, aErr := strconv.Atoi(as[i])
+		bi, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			if ai != bi {
+				if ai < bi {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -0,0 +1,22 @@
+package ext
+
+import "testing"
+
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.6.4", "1.6.4", 0},
+		{"1.6.4", "1.10.0", -1},
+		{"3.5.0-1.pgdg120+1", "3.4.3-2.pgdg120+1", 1},
+		{"0.7.0", "0.7.0-1", -1},
+		{"2.17.2-1PGDG.rhel9", "2.17.10-1PGDG.rhel9", -1},
+	}
+	for _, tt := range tests {
+		got := compareVersion(tt.a, tt.b)
+		if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+			t.Errorf("compareVersion(%q, %q) = %d, want sign of %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
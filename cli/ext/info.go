@@ -3,60 +3,186 @@ package ext
 import (
 	"bytes"
 	"fmt"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+
+	"github.com/sirupsen/logrus"
+)
+
+// templateFuncs are helper functions shared by extension templates
+var templateFuncs = template.FuncMap{
+	"join": join,
+	"pad":  pad,
+	"fit":  fit,
+}
+
+// NewExtensionTemplate compiles a user-supplied template with extension helpers
+func NewExtensionTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format template: %v", err)
+	}
+	return tmpl, nil
+}
+
+const (
+	DefaultInfoWidth = 78 // default width of the info box
+	MinInfoWidth     = 60 // minimal width of the info box
 )
 
+// InfoWidth is the width of the info box, default width is used if 0
+var InfoWidth int
+
+// boxPadRe matches the padding function of a template field
+var boxPadRe = regexp.MustCompile(`(?:pad|fit) (\d+)`)
+
+// CheckInfoWidth validates the info box width
+func CheckInfoWidth(width int) error {
+	if width != 0 && width < MinInfoWidth {
+		return fmt.Errorf("info box width %d is too small, should be at least %d", width, MinInfoWidth)
+	}
+	return nil
+}
+
+// resizeBox reflows the box template of default width to given width
+// border lines are stretched, the last field or trailing spaces of each row absorb the difference
+func resizeBox(text string, width int) string {
+	delta := width - DefaultInfoWidth
+	if width == 0 || delta == 0 {
+		return text
+	}
+	border := strings.Repeat("─", DefaultInfoWidth-2)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.Contains(line, border):
+			lines[i] = strings.Replace(line, border, strings.Repeat("─", width-2), 1)
+		case strings.HasSuffix(line, "│"):
+			if locs := boxPadRe.FindAllStringSubmatchIndex(line, -1); len(locs) > 0 {
+				loc := locs[len(locs)-1]
+				n, _ := strconv.Atoi(line[loc[2]:loc[3]])
+				fn := "pad"
+				if delta < 0 {
+					fn = "fit" // truncate values that no longer fit in narrower box
+				}
+				lines[i] = line[:loc[0]] + fn + " " + strconv.Itoa(n+delta) + line[loc[1]:]
+			} else {
+				body := strings.TrimSuffix(line, "│")
+				trimmed := strings.TrimRight(body, " ")
+				gap := max(len(body)-len(trimmed)+delta, 1)
+				lines[i] = trimmed + strings.Repeat(" ", gap) + "│"
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PrintInfo prints extension information in a unicode box
 func (e *Extension) PrintInfo() {
-	tmpl, err := template.New("extension").Funcs(template.FuncMap{
-		"join": join,
-	}).Parse(extensionInfoTmpl)
+	e.printTemplate(resizeBox(extensionInfoTmpl, InfoWidth))
+}
+
+// PrintInfoPlain prints extension information as plain key: value lines without box
+func (e *Extension) PrintInfoPlain() {
+	e.printTemplate(extensionPlainTmpl)
+}
+
+// MinimalInfo returns a one-line summary: name version [category, license] — description; needs: requires
+func (e *Extension) MinimalInfo() string {
+	line := fmt.Sprintf("%s %s [%s, %s] — %s", utils.Colorize(utils.ColorGreen, e.Name), e.PackageVersion(),
+		strings.ToLower(e.Category), e.License, e.EnDesc)
+	if len(e.Requires) > 0 {
+		line += "; needs: " + strings.Join(e.Requires, ", ")
+	}
+	return line
+}
+
+func (e *Extension) printTemplate(text string) {
+	out, err := e.renderTemplate(text)
 	if err != nil {
-		fmt.Printf("Error parsing template: %v\n", err)
+		fmt.Println(err)
 		return
 	}
+	fmt.Println(out)
+}
 
+// renderTemplate renders the extension with given template text
+func (e *Extension) renderTemplate(text string) (string, error) {
+	tmpl, err := template.New("extension").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing template: %v", err)
+	}
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, e); err != nil {
-		fmt.Printf("Error executing template: %v\n", err)
-		return
+		return "", fmt.Errorf("Error executing template: %v", err)
 	}
-
-	fmt.Println(buf.String())
+	return buf.String(), nil
 }
 
 const extensionInfoTmpl = `
 ╭────────────────────────────────────────────────────────────────────────────╮
-│ {{ printf "%-74s" .Name   }} │
+│ {{ pad 74 .Name   }} │
 ├────────────────────────────────────────────────────────────────────────────┤
-│ {{ printf "%-74s" .EnDesc }} │
+│ {{ pad 74 .EnDesc }} │
+{{- with .DeprecatedNote }}
 ├────────────────────────────────────────────────────────────────────────────┤
-│ Extension : {{ printf "%-62s" .Name        }} │
-│ Alias     : {{ printf "%-62s" .Alias       }} │
-│ Category  : {{ printf "%-62s" .Category    }} │
-│ Version   : {{ printf "%-62s" .Version     }} │
-│ License   : {{ printf "%-62s" .License     }} │
-│ Website   : {{ printf "%-62s" .URL         }} │
-│ Details   : {{ printf "%-62s" .SummaryURL  }} │
+│ {{ pad 74 . }} │
+{{- end }}
+├────────────────────────────────────────────────────────────────────────────┤
+│ Extension : {{ pad 62 .Name        }} │
+│ Alias     : {{ pad 62 .Alias       }} │
+{{- if .Provides }}
+│ Provides  : {{ pad 62 (join .Provides ", ") }} │
+{{- end }}
+│ Category  : {{ pad 62 .Category    }} │
+│ Version   : {{ pad 62 .Version     }} │
+│ License   : {{ pad 62 .License     }} │
+│ Website   : {{ pad 62 .URL         }} │
+│ Details   : {{ pad 62 .SummaryURL  }} │
+{{- with .SourceURL }}
+│ Source    : {{ pad 62 . }} │
+{{- end }}
+{{- with .LiveStatus }}
+│ Status    : {{ pad 62 . }} │
+{{- end }}
 ├────────────────────────────────────────────────────────────────────────────┤
 │ Extension Properties                                                       │
 ├────────────────────────────────────────────────────────────────────────────┤
-│ PostgreSQL Ver │  Available on: {{ printf "%-42s" (join .PgVer ", ") }} │
-│ CREATE  :  {{ if .NeedDDL  }}Yes{{ else }}No {{ end }} │  {{ printf "%-56s" .CreateSQL }} │
-│ DYLOAD  :  {{ if .NeedLoad }}Yes{{ else }}No {{ end }} │  {{ printf "%-56s" .SharedLib }} │
-│ {{ printf "%-74s" .SuperUser }} │
-│ Reloc   :  {{ if eq .Relocatable "t" }}Yes{{ else }}No {{ end }} │  {{ printf "%-56s" .SchemaStr }} │
+│ PostgreSQL Ver │  Available on: {{ pad 42 (join .PgVer ", ") }} │
+│ CREATE  :  {{ if .NeedDDL  }}Yes{{ else }}No {{ end }} │  {{ pad 56 .CreateSQL }} │
+│ DYLOAD  :  {{ if .NeedLoad }}Yes{{ else }}No {{ end }} │  {{ pad 56 .SharedLib }} │
+│ SUPER   :  {{ pad 3 (.GetBool "superuser") }} │  {{ pad 56 .SuperUserNote }} │
+│ TRUST   :  {{ pad 3 (.GetBool "trusted") }} │  {{ pad 56 .TrustedNote }} │
+│ Reloc   :  {{ if eq .Relocatable "t" }}Yes{{ else }}No {{ end }} │  {{ pad 56 .SchemaStr }} │
 {{- if .Requires }}
-│ Depend  :  Yes │  {{ printf "%-56s" (join .Requires ", ") }} │
+│ Depend  :  Yes │  {{ pad 56 .RequiresStatus }} │
 {{- else }}
 │ Depend  :  No  │                                                           │
 {{- end }}
+{{- if .Conflicts }}
+│ Conflict:  Yes │  {{ pad 56 (join .Conflicts ", ") }} │
+{{- end }}
 {{- if .DependsOn }}
 ├────────────────────────────────────────────────────────────────────────────┤
 │ Required By                                                                │
 ├────────────────────────────────────────────────────────────────────────────┤
 {{- range .DependsOn }}
-│ - {{ printf "%-72s" . }} │
+│ - {{ pad 72 . }} │
+{{- end }}
+{{- end }}
+
+{{- with .ConfigLines }}
+├────────────────────────────────────────────────────────────────────────────┤
+│ Configuration                                                              │
+├────────────────────────────────────────────────────────────────────────────┤
+{{- range . }}
+│ {{ pad 74 . }} │
 {{- end }}
 {{- end }}
 
@@ -64,12 +190,12 @@ const extensionInfoTmpl = `
 ├────────────────────────────────────────────────────────────────────────────┤
 │ RPM Package                                                                │
 ├────────────────────────────────────────────────────────────────────────────┤
-│ Repository     │  {{ printf "%-56s" .RpmRepo }} │
-│ Package        │  {{ printf "%-56s" .RpmPkg  }} │
-│ Version        │  {{ printf "%-56s" .RpmVer  }} │
-│ Availability   │  {{ printf "%-56s" (join .RpmPg ", ") }} │
+│ Repository     │  {{ pad 56 .RpmRepo }} │
+│ Package        │  {{ pad 56 .RpmPkg  }} │
+│ Version        │  {{ pad 56 .RpmVersions }} │
+│ Availability   │  {{ pad 56 (join .RpmPg ", ") }} │
 {{- if .DebDeps }}
-│ Dependencies   │  {{ printf "%-56s" (join .RpmDeps ", ") }} │
+│ Dependencies   │  {{ pad 56 (join .RpmDeps ", ") }} │
 {{- end }}
 {{- end }}
 
@@ -77,21 +203,30 @@ const extensionInfoTmpl = `
 ├────────────────────────────────────────────────────────────────────────────┤
 │ DEB Package                                                                │
 ├────────────────────────────────────────────────────────────────────────────┤
-│ Repository     │  {{ printf "%-56s" .DebRepo }} │
-│ Package        │  {{ printf "%-56s" .DebPkg  }} │
-│ Version        │  {{ printf "%-56s" .DebVer  }} │
-│ Availability   │  {{ printf "%-56s" (join .DebPg ", ") }} │
+│ Repository     │  {{ pad 56 .DebRepo }} │
+│ Package        │  {{ pad 56 .DebPkg  }} │
+│ Version        │  {{ pad 56 .DebVersions }} │
+│ Availability   │  {{ pad 56 (join .DebPg ", ") }} │
 {{- if .DebDeps }}
-│ Dependencies   │  {{ printf "%-56s" (join .DebDeps ", ") }} │
+│ Dependencies   │  {{ pad 56 (join .DebDeps ", ") }} │
 {{- end }}
 {{- end }}
 
+{{- if .SourceOnly }}
+├────────────────────────────────────────────────────────────────────────────┤
+│ Build From Source                                                          │
+├────────────────────────────────────────────────────────────────────────────┤
+│ Source    : {{ pad 62 .BuildSource   }} │
+│ Requires  : {{ pad 62 .BuildRequires }} │
+│ {{ pad 74 .NoPackageNote }} │
+{{- end }}
+
 {{- if .BadCase }}
 ├────────────────────────────────────────────────────────────────────────────┤
 │ Known Issues                                                               │
 ├────────────────────────────────────────────────────────────────────────────┤
 {{- range .BadCase }}
-│ {{ printf "%-74s" . }} │
+│ {{ pad 74 . }} │
 {{- end }}
 {{- end }}
 
@@ -99,11 +234,243 @@ const extensionInfoTmpl = `
 ├────────────────────────────────────────────────────────────────────────────┤
 │ Additional Comments                                                        │
 ├────────────────────────────────────────────────────────────────────────────┤
-│ {{ printf "%-74s" .Comment }} │
+│ {{ pad 74 .Comment }} │
 {{- end }}
 ╰────────────────────────────────────────────────────────────────────────────╯
 `
 
+const extensionPlainTmpl = `{{ .Name }}: {{ .EnDesc }}
+{{- with .DeprecatedNote }}
+{{ . }}
+{{- end }}
+Extension   : {{ .Name }}
+Alias       : {{ .Alias }}
+{{- if .Provides }}
+Provides    : {{ join .Provides ", " }}
+{{- end }}
+Category    : {{ .Category }}
+Version     : {{ .Version }}
+License     : {{ .License }}
+Website     : {{ .URL }}
+Details     : {{ .SummaryURL }}
+{{- with .SourceURL }}
+Source      : {{ . }}
+{{- end }}
+{{- with .LiveStatus }}
+Status      : {{ . }}
+{{- end }}
+PG Versions : {{ join .PgVer ", " }}
+Create      : {{ .GetBool "ddl" }} ({{ .CreateSQL }})
+Load        : {{ .GetBool "load" }} ({{ .SharedLib }})
+SuperUser   : {{ .GetBool "superuser" }} ({{ .SuperUserNote }})
+Trusted     : {{ .GetBool "trusted" }} ({{ .TrustedNote }})
+Relocatable : {{ .GetBool "relocatable" }} ({{ .SchemaStr }})
+Requires    : {{ if .Requires }}{{ .RequiresStatus }}{{ else }}none{{ end }}
+{{- if .Conflicts }}
+Conflicts   : {{ join .Conflicts ", " }}
+{{- end }}
+{{- if .DependsOn }}
+Required By :
+{{- range .DependsOn }}
+  - {{ . }}
+{{- end }}
+{{- end }}
+{{- with .ConfigLines }}
+Configuration :
+{{- range . }}
+  - {{ . }}
+{{- end }}
+{{- end }}
+{{- if .RpmRepo }}
+RPM Package :
+  - Repository   : {{ .RpmRepo }}
+  - Package      : {{ .RpmPkg }}
+  - Version      : {{ .RpmVersions }}
+  - Availability : {{ join .RpmPg ", " }}
+{{- if .RpmDeps }}
+  - Dependencies : {{ join .RpmDeps ", " }}
+{{- end }}
+{{- end }}
+{{- if .DebRepo }}
+DEB Package :
+  - Repository   : {{ .DebRepo }}
+  - Package      : {{ .DebPkg }}
+  - Version      : {{ .DebVersions }}
+  - Availability : {{ join .DebPg ", " }}
+{{- if .DebDeps }}
+  - Dependencies : {{ join .DebDeps ", " }}
+{{- end }}
+{{- end }}
+{{- if .SourceOnly }}
+Build From Source :
+  - Source       : {{ .BuildSource }}
+  - Requires     : {{ .BuildRequires }}
+  - Note         : {{ .NoPackageNote }}
+{{- end }}
+{{- if .BadCase }}
+Known Issues:
+{{- range .BadCase }}
+  - {{ . }}
+{{- end }}
+{{- end }}
+{{- if .Comment }}
+Comment     : {{ .Comment }}
+{{- end }}
+`
+
+// AllFields returns every field of the extension as key / value pairs in struct order, keys are json names
+// derived values used by package resolution on given pg version are appended with a "resolved." prefix
+func (e *Extension) AllFields(pgVer int) [][2]string {
+	var fields [][2]string
+	v := reflect.ValueOf(e).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			key = field.Name
+		}
+		value := v.Field(i)
+		var str string
+		if value.Kind() == reflect.Slice {
+			items := make([]string, value.Len())
+			for j := range items {
+				items[j] = fmt.Sprint(value.Index(j).Interface())
+			}
+			str = "[" + strings.Join(items, ", ") + "]"
+		} else {
+			str = fmt.Sprint(value.Interface())
+		}
+		fields = append(fields, [2]string{key, str})
+	}
+	return append(fields,
+		[2]string{"resolved.os", config.OSCode + "." + config.OSArch},
+		[2]string{"resolved.pg_version", strconv.Itoa(pgVer)},
+		[2]string{"resolved.repo", e.RepoName()},
+		[2]string{"resolved.package", e.PackageName(pgVer)},
+		[2]string{"resolved.version", e.PgPackageVersion(pgVer)},
+		[2]string{"resolved.available", strconv.FormatBool(e.Available(pgVer))},
+		[2]string{"resolved.depends_on", "[" + strings.Join(e.DependsOn(), ", ") + "]"},
+	)
+}
+
+// PrintAll prints every field of the extension as flat key: value lines, for debugging catalog data
+func (e *Extension) PrintAll(pgVer int) {
+	for _, kv := range e.AllFields(pgVer) {
+		fmt.Printf("%-20s : %s\n", kv[0], kv[1])
+	}
+}
+
+// PackageInfo is the package metadata of an extension on a package type (rpm / deb)
+type PackageInfo struct {
+	Name         string         `json:"name"`
+	Type         string         `json:"type"`
+	Repository   string         `json:"repository"`
+	Package      string         `json:"package"`
+	Version      string         `json:"version"`
+	PgVersions   map[int]string `json:"pg_versions,omitempty"`
+	Availability []string       `json:"availability"`
+	Dependencies []string       `json:"dependencies"`
+}
+
+// PackageInfo returns the package metadata of given package type: rpm or deb
+func (e *Extension) PackageInfo(pkgType string) *PackageInfo {
+	if pkgType == "rpm" {
+		return &PackageInfo{Name: e.Name, Type: pkgType, Repository: e.RpmRepo, Package: e.RpmPkg, Version: e.RpmVer, PgVersions: e.RpmPgVer, Availability: e.RpmPg, Dependencies: e.RpmDeps}
+	}
+	return &PackageInfo{Name: e.Name, Type: pkgType, Repository: e.DebRepo, Package: e.DebPkg, Version: e.DebVer, PgVersions: e.DebPgVer, Availability: e.DebPg, Dependencies: e.DebDeps}
+}
+
+// Print prints the package metadata as key: value lines
+func (p *PackageInfo) Print() {
+	fmt.Printf("Extension    : %s\n", p.Name)
+	fmt.Printf("Type         : %s\n", p.Type)
+	fmt.Printf("Repository   : %s\n", p.Repository)
+	fmt.Printf("Package      : %s\n", p.Package)
+	fmt.Printf("Version      : %s\n", formatPgVersions(p.Version, p.PgVersions))
+	fmt.Printf("Availability : %s\n", strings.Join(p.Availability, ", "))
+	fmt.Printf("Dependencies : %s\n", strings.Join(p.Dependencies, ", "))
+}
+
+// Examples returns example commands to install, load and create the extension
+func (e *Extension) Examples() []string {
+	examples := []string{"pig ext add " + e.Name + "    # install extension packages"}
+	for _, line := range e.ConfigLines() {
+		examples = append(examples, "# postgresql.conf: "+line+" (restart required)")
+	}
+	if !e.NeedDDL {
+		return append(examples, "# no CREATE EXTENSION needed")
+	}
+	switch e.Trusted {
+	case "t":
+		examples = append(examples, e.CreateSQL()+"    -- trusted: non-superuser with CREATE privilege on database can run it (PG13+)")
+	case "f":
+		examples = append(examples, e.CreateSQL()+"    -- untrusted: only superuser can run it")
+	default:
+		examples = append(examples, e.CreateSQL()+"    -- trust unknown: may require superuser")
+	}
+	return examples
+}
+
+// LinkURL returns the url of given link target: home (website), summary (catalog page) or source (repository)
+func (e *Extension) LinkURL(target string) (string, error) {
+	switch target {
+	case "", "home":
+		if e.URL == "" {
+			return "", fmt.Errorf("extension %s has no website", e.Name)
+		}
+		return e.URL, nil
+	case "summary":
+		return e.SummaryURL(), nil
+	case "source":
+		if url := e.SourceURL(); url != "" {
+			return url, nil
+		}
+		if strings.Contains(e.URL, "github.com") || strings.Contains(e.URL, "gitlab") {
+			return e.URL, nil
+		}
+		return "", fmt.Errorf("extension %s has no known source repository", e.Name)
+	}
+	return "", fmt.Errorf("invalid open target %q, should be home, summary or source", target)
+}
+
+// LiveStatus returns the installed & enabled state on the designated PostgreSQL
+// empty string is returned if there's no PostgreSQL available
+func (e *Extension) LiveStatus() string {
+	if Postgres == nil {
+		return ""
+	}
+	installed := "Installed: no"
+	if ei, ok := Postgres.ExtensionMap[e.Name]; ok {
+		installed = fmt.Sprintf("Installed: yes (%s)", ei.ActiveVersion())
+	}
+	dbExts, err := ScanDatabaseExtensions()
+	if err != nil {
+		logrus.Debugf("failed to scan database extensions: %v", err)
+		return installed + " / Enabled in: unknown"
+	}
+	var enabled []string
+	for db, exts := range dbExts {
+		if _, ok := exts[e.Name]; ok {
+			enabled = append(enabled, db)
+		}
+	}
+	if len(enabled) == 0 {
+		return installed + " / Enabled in: none"
+	}
+	sort.Strings(enabled)
+	return installed + " / Enabled in: " + strings.Join(enabled, ", ")
+}
+
+// pad pads a value to given display width, east asian wide runes count as two columns
+func pad(n int, s string) string {
+	return utils.PadWidth(s, n)
+}
+
+// fit pads a value to given display width, and truncates it if too wide
+func fit(n int, s string) string {
+	return utils.PadWidth(utils.TruncateWidth(s, n), n)
+}
+
 func join(strs []string, sep string) string {
 	return strings.Join(strs, sep)
 }
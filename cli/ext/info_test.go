@@ -0,0 +1,187 @@
+package ext
+
+import (
+	"pig/internal/config"
+	"pig/internal/utils"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestInfoSuperUserTrusted(t *testing.T) {
+	tests := []struct {
+		ext       *Extension
+		superuser string
+		trusted   string
+	}{
+		{&Extension{Name: "pg_cron", Trusted: "f"}, "│ SUPER   :  Yes │", "│ TRUST   :  No  │"},
+		{&Extension{Name: "pgcrypto", Trusted: "t"}, "│ SUPER   :  No  │", "│ TRUST   :  Yes │"},
+	}
+	for _, tt := range tests {
+		out, err := tt.ext.renderTemplate(extensionInfoTmpl)
+		if err != nil {
+			t.Fatalf("render %s: %v", tt.ext.Name, err)
+		}
+		if !strings.Contains(out, tt.superuser) {
+			t.Errorf("%s: missing superuser row %q", tt.ext.Name, tt.superuser)
+		}
+		if !strings.Contains(out, tt.trusted) {
+			t.Errorf("%s: missing trusted row %q", tt.ext.Name, tt.trusted)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "│ SUPER") || strings.HasPrefix(line, "│ TRUST") {
+				if n := utf8.RuneCountInString(line); n != 78 {
+					t.Errorf("%s: row width %d, want 78: %q", tt.ext.Name, n, line)
+				}
+			}
+		}
+	}
+}
+
+func TestSourceURL(t *testing.T) {
+	tests := []struct {
+		ext  *Extension
+		want string
+	}{
+		{&Extension{Name: "vector", URL: "https://github.com/pgvector/pgvector"}, ""},
+		{&Extension{Name: "vector", URL: "https://github.com/pgvector/pgvector", Source: "https://github.com/pgvector/pgvector/"}, ""},
+		{&Extension{Name: "postgis", URL: "https://postgis.net", Source: "https://github.com/postgis/postgis"}, "https://github.com/postgis/postgis"},
+		{&Extension{Name: "pg_trgm", Repo: "CONTRIB", URL: "https://www.postgresql.org/docs/current/pgtrgm.html"}, "https://github.com/postgres/postgres"},
+		{&Extension{Name: "pgpool", URL: "https://pgpool.net"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.ext.SourceURL(); got != tt.want {
+			t.Errorf("%s: SourceURL() = %q, want %q", tt.ext.Name, got, tt.want)
+		}
+	}
+}
+
+func TestInfoWidth(t *testing.T) {
+	e := &Extension{Name: "pg_cron", Trusted: "f", NeedLoad: true, NeedDDL: true, Requires: []string{"plpgsql"}, PgVer: []string{"17", "16"},
+		DebRepo: "PGDG", DebPkg: "postgresql-$v-cron", DebVer: "1.6.4", Comment: "cron job scheduler"}
+	for _, width := range []int{60, 78, 100} {
+		out, err := e.renderTemplate(resizeBox(extensionInfoTmpl, width))
+		if err != nil {
+			t.Fatalf("render at width %d: %v", width, err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if n := utf8.RuneCountInString(line); n != width {
+				t.Errorf("width %d: line has %d columns: %q", width, n, line)
+			}
+		}
+	}
+	if err := CheckInfoWidth(MinInfoWidth - 1); err == nil {
+		t.Errorf("width %d should be rejected", MinInfoWidth-1)
+	}
+}
+
+func TestInfoCJKWidth(t *testing.T) {
+	e := &Extension{Name: "pg_jieba", EnDesc: "结巴中文分词器 Chinese full text search", NeedDDL: true, PgVer: []string{"17"},
+		Comment: "需要配置 shared_preload_libraries 才能使用自定义词典，重启生效"}
+	for _, width := range []int{60, 78} {
+		out, err := e.renderTemplate(resizeBox(extensionInfoTmpl, width))
+		if err != nil {
+			t.Fatalf("render at width %d: %v", width, err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if n := utils.DisplayWidth(line); n != width {
+				t.Errorf("width %d: line has %d display columns: %q", width, n, line)
+			}
+		}
+		if !strings.Contains(out, "结巴中文分词器") {
+			t.Errorf("width %d: chinese description is missing", width)
+		}
+	}
+}
+
+func TestRequiresStatus(t *testing.T) {
+	savedCatalog, savedPostgres, savedOS := Catalog, Postgres, config.OSType
+	defer func() { Catalog, Postgres, config.OSType = savedCatalog, savedPostgres, savedOS }()
+	config.OSType, Postgres = config.DistroDEB, nil
+	Catalog = &ExtensionCatalog{ExtNameMap: map[string]*Extension{
+		"geo_ok":   {Name: "geo_ok", DebPkg: "postgresql-$v-geo", DebPg: []string{"17", "16"}},
+		"geo_old":  {Name: "geo_old", DebPkg: "postgresql-$v-old", DebPg: []string{"13"}},
+		"rpm_only": {Name: "rpm_only", RpmPkg: "rpm_only_$v", RpmPg: []string{"17"}},
+	}}
+	e := &Extension{Name: "geo_app", Requires: []string{"geo_ok", "geo_old", "rpm_only", "missing"}}
+	if got, want := e.RequiresStatus(), "geo_ok ✓, geo_old ✗, rpm_only ✗, missing ✗"; got != want {
+		t.Errorf("RequiresStatus() = %q, want %q", got, want)
+	}
+}
+
+func TestAllFields(t *testing.T) {
+	e := &Extension{ID: 1070, Name: "pg_cron", Schemas: []string{"pg_catalog"}, Provides: []string{"cron"}}
+	fields := e.AllFields(16)
+	got := make(map[string]string, len(fields))
+	for _, kv := range fields {
+		got[kv[0]] = kv[1]
+	}
+	if n := reflect.TypeOf(Extension{}).NumField(); len(fields) < n {
+		t.Errorf("AllFields() returns %d fields, struct has %d", len(fields), n)
+	}
+	for key, want := range map[string]string{"id": "1070", "name": "pg_cron", "schemas": "[pg_catalog]", "provides": "[cron]", "conflicts": "[]", "resolved.pg_version": "16"} {
+		if got[key] != want {
+			t.Errorf("field %s = %q, want %q", key, got[key], want)
+		}
+	}
+}
+
+func TestInfoSourceOnly(t *testing.T) {
+	savedOS := config.OSType
+	defer func() { config.OSType = savedOS }()
+	config.OSType = config.DistroDEB
+	e := &Extension{Name: "pg_build", URL: "https://example.com/pg_build", Source: "https://github.com/example/pg_build", Requires: []string{"plpgsql"},
+		RpmRepo: "PIGSTY", RpmPkg: "pg_build_$v"}
+	out, err := e.renderTemplate(extensionInfoTmpl)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	for _, want := range []string{"│ Build From Source", "│ Source    : https://github.com/example/pg_build", "│ Requires  : plpgsql", "No binary package for"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("source only section missing %q:\n%s", want, out)
+		}
+	}
+	e.DebRepo, e.DebPkg = "PIGSTY", "postgresql-$v-build"
+	if out, _ := e.renderTemplate(extensionInfoTmpl); strings.Contains(out, "Build From Source") {
+		t.Errorf("extension with deb package should not show build from source section")
+	}
+}
+
+func TestRelated(t *testing.T) {
+	saved := Catalog
+	defer func() { Catalog = saved }()
+	vector := &Extension{Name: "vector", Category: "RAG"}
+	Catalog = &ExtensionCatalog{Extensions: []*Extension{
+		vector,
+		{Name: "pgrouting", Category: "GIS", Requires: []string{"postgis"}},
+		{Name: "vchord", Category: "RAG", Requires: []string{"vector"}},
+		{Name: "vectorscale", Category: "RAG", Requires: []string{"vector"}},
+		{Name: "pg_cron", Category: "TIME"},
+	}}
+	var got []string
+	for _, r := range Catalog.Extensions[2].Related() {
+		got = append(got, r.Name+":"+r.Relation)
+	}
+	if strings.Join(got, " ") != "vectorscale:requires vector vector:category RAG" {
+		t.Errorf("Related() = %v", got)
+	}
+	if related := vector.Related(); len(related) != 2 {
+		t.Errorf("Related() of vector returned %d extensions, want 2", len(related))
+	}
+}
+
+func TestMinimalInfo(t *testing.T) {
+	utils.NoColor = true
+	defer func() { utils.NoColor = false }()
+	e := &Extension{Name: "vchord", Version: "0.1.0", Category: "RAG", License: "AGPL-3.0",
+		EnDesc: "Vector database plugin for Postgres", Requires: []string{"vector"}}
+	want := "vchord 0.1.0 [rag, AGPL-3.0] — Vector database plugin for Postgres; needs: vector"
+	if got := e.MinimalInfo(); got != want {
+		t.Errorf("MinimalInfo() = %q, want %q", got, want)
+	}
+	e.Requires = nil
+	if got := e.MinimalInfo(); strings.Contains(got, "needs") {
+		t.Errorf("MinimalInfo() without requires = %q", got)
+	}
+}
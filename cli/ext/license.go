@@ -0,0 +1,104 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// licenseNames are canonical license names used in catalog
+var licenseNames = []string{
+	"PostgreSQL", "MIT", "ISC", "Apache-2.0", "BSD 0-Clause", "BSD 2-Clause", "BSD 3-Clause", "Artistic",
+	"MPL-2.0", "LGPL-2.1", "LGPL-3.0", "GPL-2.0", "GPL-3.0", "AGPL-3.0",
+}
+
+// licenseAliases maps common spellings (in license key form) to canonical license names
+var licenseAliases = map[string]string{
+	"postgres": "PostgreSQL", "pgsql": "PostgreSQL",
+	"apache": "Apache-2.0", "apache2": "Apache-2.0", "apachev2": "Apache-2.0",
+	"bsd": "BSD 3-Clause", "bsd3": "BSD 3-Clause", "newbsd": "BSD 3-Clause", "bsd2": "BSD 2-Clause", "simplifiedbsd": "BSD 2-Clause", "0bsd": "BSD 0-Clause",
+	"gpl2": "GPL-2.0", "gplv2": "GPL-2.0", "gpl3": "GPL-3.0", "gplv3": "GPL-3.0", "agpl3": "AGPL-3.0", "agplv3": "AGPL-3.0",
+	"lgpl2.1": "LGPL-2.1", "lgplv2.1": "LGPL-2.1", "lgpl3": "LGPL-3.0", "lgplv3": "LGPL-3.0", "mpl2": "MPL-2.0",
+}
+
+// licenseKey folds a license spelling: lower case, without "license", "clause", spaces and dashes
+func licenseKey(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "the ")
+	for _, r := range []string{"license", " ", "-", "_", "clause", "only"} {
+		s = strings.ReplaceAll(s, r, "")
+	}
+	return strings.TrimSuffix(s, ".0")
+}
+
+// NormalizeLicense returns the canonical name of a license spelling, unknown licenses are returned as is
+func NormalizeLicense(s string) string {
+	key := licenseKey(s)
+	for _, name := range licenseNames {
+		if licenseKey(name) == key {
+			return name
+		}
+	}
+	if name, ok := licenseAliases[key]; ok {
+		return name
+	}
+	return strings.TrimSpace(s)
+}
+
+// Copyleft returns the copyleft strength of a license: strong, weak, no, or - if unknown
+func Copyleft(license string) string {
+	name := NormalizeLicense(license)
+	switch {
+	case !slices.Contains(licenseNames, name):
+		return "-"
+	case strings.HasPrefix(name, "GPL") || strings.HasPrefix(name, "AGPL"):
+		return "strong"
+	case strings.HasPrefix(name, "LGPL") || strings.HasPrefix(name, "MPL"):
+		return "weak"
+	}
+	return "no"
+}
+
+// FilterLicense returns extensions with one of given licenses, spellings are normalized before matching
+func FilterLicense(exts []*Extension, licenses []string) []*Extension {
+	wanted := make(map[string]bool, len(licenses))
+	for _, l := range licenses {
+		wanted[NormalizeLicense(l)] = true
+	}
+	var result []*Extension
+	for _, ext := range exts {
+		if wanted[NormalizeLicense(ext.License)] {
+			result = append(result, ext)
+		}
+	}
+	return result
+}
+
+// PrintLicenses prints catalog extension count by license
+func (ec *ExtensionCatalog) PrintLicenses() {
+	count := make(map[string]int)
+	for _, ext := range ec.Extensions {
+		count[NormalizeLicense(ext.License)]++
+	}
+	licenses := make([]string, 0, len(count))
+	for l := range count {
+		licenses = append(licenses, l)
+	}
+	sort.Slice(licenses, func(i, j int) bool {
+		if count[licenses[i]] != count[licenses[j]] {
+			return count[licenses[i]] > count[licenses[j]]
+		}
+		return licenses[i] < licenses[j]
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "License\tCount\tCopyleft")
+	fmt.Fprintln(w, "-------\t-----\t--------")
+	for _, l := range licenses {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", l, count[l], Copyleft(l))
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows) (%d Extensions)\n\n", len(licenses), len(ec.Extensions))
+}
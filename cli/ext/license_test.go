@@ -0,0 +1,32 @@
+package ext
+
+import "testing"
+
+func TestNormalizeLicense(t *testing.T) {
+	tests := map[string]string{
+		"PostgreSQL":          "PostgreSQL",
+		"PostgreSQL License":  "PostgreSQL",
+		"postgresql":          "PostgreSQL",
+		"Apache 2.0":          "Apache-2.0",
+		"Apache License 2.0":  "Apache-2.0",
+		"BSD-3-Clause":        "BSD 3-Clause",
+		"bsd 2 clause":        "BSD 2-Clause",
+		"GPLv2":               "GPL-2.0",
+		"GPL-3.0-only":        "GPL-3.0",
+		"MIT License":         "MIT",
+		"Some Custom License": "Some Custom License",
+	}
+	for input, want := range tests {
+		if got := NormalizeLicense(input); got != want {
+			t.Errorf("NormalizeLicense(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestFilterLicense(t *testing.T) {
+	data := []*Extension{{Name: "a", License: "PostgreSQL"}, {Name: "b", License: "GPL-2.0"}, {Name: "c", License: "MIT"}}
+	got := FilterLicense(data, []string{"PostgreSQL License", "mit"})
+	if len(got) != 2 || got[0].Name != "a" || got[1].Name != "c" {
+		t.Errorf("FilterLicense() = %v", got)
+	}
+}
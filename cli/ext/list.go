@@ -1,12 +1,15 @@
 package ext
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"pig/internal/config"
+	"pig/internal/utils"
+	"slices"
 	"sort"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/sirupsen/logrus"
 )
@@ -35,46 +38,227 @@ type SearchResult struct {
 	Score     float64
 }
 
+// Highlight is the search query highlighted in extension names and descriptions of list tables
+var Highlight string
+
+// WideDesc prints full descriptions in list tables, wrapped to multiple lines instead of elided
+var WideDesc bool
+
+// ListWidth is the width tables shrink their description column to fit, detected from terminal if 0
+var ListWidth int
+
+const (
+	defaultDescWidth = 64 // description width when output width is unknown (e.g. piped)
+	minDescWidth     = 16 // description is never shrunk below this width
+)
+
+// tableWidth returns the width tables should fit in, 0 if unknown
+func tableWidth() int {
+	if ListWidth > 0 {
+		return ListWidth
+	}
+	return utils.TerminalWidth()
+}
+
+// writeTable writes rows with columns padded to their display width (PadWidth/DisplayWidth, so CJK cells align),
+// the last column of rows after header is elided with "…" to fit given width, or wrapped if WideDesc is set
+// the last column is cut at defaultDescWidth if width is 0
+func writeTable(out io.Writer, rows [][]string, header int, width int) {
+	if len(rows) == 0 {
+		return
+	}
+	last := len(rows[0]) - 1
+	colWidths := make([]int, last)
+	used := 0
+	for col := 0; col < last; col++ {
+		for _, row := range rows {
+			colWidths[col] = max(colWidths[col], utils.DisplayWidth(row[col]))
+		}
+		used += colWidths[col] + 2
+	}
+	descWidth := defaultDescWidth
+	if width > 0 {
+		descWidth = max(width-used, minDescWidth)
+	}
+	var buf strings.Builder
+	for i, row := range rows {
+		desc := row[last]
+		var more []string
+		if WideDesc && i >= header {
+			lines := utils.WrapWidth(desc, descWidth)
+			desc, more = lines[0], lines[1:]
+		}
+		if utils.DisplayWidth(desc) > descWidth {
+			if i < header {
+				desc = utils.TruncateWidth(desc, descWidth)
+			} else {
+				desc = utils.TruncateWidth(desc, descWidth-1) + "…"
+			}
+		}
+		buf.Reset()
+		for col := 0; col < last; col++ {
+			cell := row[col]
+			if col == 0 && i >= header {
+				cell = utils.Highlight(cell, Highlight)
+			}
+			// pad by display width, so wide runes and color sequences stay aligned
+			buf.WriteString(utils.PadWidth(cell, colWidths[col]+2))
+		}
+		if i >= header {
+			desc = utils.Highlight(desc, Highlight)
+		}
+		buf.WriteString(desc)
+		fmt.Fprintln(out, buf.String())
+		for _, line := range more {
+			fmt.Fprintln(out, strings.Repeat(" ", used)+utils.Highlight(line, Highlight))
+		}
+	}
+}
+
 // TabulteVersion prints a tabulated list of extensions available to given version
+// available extensions are checked against repo packages (and the installed minor version) if StrictVersion is set
 func TabulteVersion(pgVer int, data []*Extension) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Name\tState\tVersion\tCate\tFlags\tLicense\tRepo\tPGVer\tPackage\tDescription")
-	fmt.Fprintln(w, "----\t-----\t-------\t----\t------\t-------\t------\t-----\t------------\t---------------------")
+	var repo map[string]bool
+	if StrictVersion {
+		var err error
+		if repo, err = repoPackageNames(); err != nil {
+			logrus.Warnf("skip strict version check: %v", err)
+		} else if Postgres != nil {
+			dropNewerMinor(data, Postgres, repo)
+		}
+	}
+	tabulateVersion(os.Stdout, pgVer, data, tableWidth(), repo)
+}
+
+// tabulateVersion writes the version tabulated list to given writer, fit in given width
+// version and availability columns are padded to a width computed from data, and versions are right-aligned
+// if repo packages are given, available extensions without a package in repo are marked as norepo
+func tabulateVersion(out io.Writer, pgVer int, data []*Extension, width int, repo map[string]bool) {
 	if Postgres != nil {
 		pgVer = Postgres.MajorVersion
 	}
+	verWidth, availWidth := len("Version"), len("PGVer")
+	for _, ext := range data {
+		verWidth = max(verWidth, len(ext.PgPackageVersion(pgVer)))
+		availWidth = max(availWidth, len(ext.Availability(config.OSCode)))
+	}
+
+	rows := [][]string{
+		{"Name", "State", fmt.Sprintf("%*s", verWidth, "Version"), "Cate", "Flags", "License", "Repo", fmt.Sprintf("%-*s", availWidth, "PGVer"), "Package", "Description"},
+		{"----", "-----", strings.Repeat("-", verWidth), "----", "------", "-------", "------", strings.Repeat("-", availWidth), "------------", "---------------------"},
+	}
 	for _, ext := range data {
-		desc := ext.EnDesc
-		if len(desc) > 64 {
-			desc = desc[:64]
-		}
 		pkgStr := ext.PackageName(pgVer)
 		if strings.Contains(pkgStr, "$v") {
 			pkgStr = fmt.Sprintf("[%s]", pkgStr)
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			ext.Name, ext.GetStatus(pgVer), ext.Version, ext.Category, ext.GetFlag(), ext.License, ext.RepoName(), ext.Availability(config.OSCode), pkgStr, desc)
+		status := ext.GetStatus(pgVer)
+		if repo != nil && status == "avail" && !hasRepoPackage(ext, pgVer, repo) {
+			status = "norepo"
+		}
+		rows = append(rows, []string{ext.Name, status, fmt.Sprintf("%*s", verWidth, ext.PgPackageVersion(pgVer)), ext.Category, ext.GetFlag(), ext.License,
+			ext.RepoName(), fmt.Sprintf("%-*s", availWidth, ext.Availability(config.OSCode)), pkgStr, ext.ListDesc()})
+	}
+	states := "added|avail|n/a"
+	if repo != nil {
+		states = "added|avail|norepo|n/a"
 	}
-	w.Flush()
-	fmt.Printf("\n(%d Rows) (State: added|avail|n/a,Flags: b = HasBin, d = HasDDL, s = HasSolib, l = NeedLoad, t = Trusted, r = Relocatable, x = Unknown)\n\n", len(data))
+	writeTable(out, rows, 2, width)
+	fmt.Fprintf(out, "\n(%d Rows) (State: %s,Flags: b = HasBin, d = HasDDL, s = HasSolib, l = NeedLoad, t = Trusted, r = Relocatable, x = Unknown)\n\n", len(data), states)
 }
 
 func TabulteCommon(data []*Extension) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Name\tVersion\tCate\tFlags\tLicense\tRPM\tDEB\tPG Ver\tDescription")
-	fmt.Fprintln(w, "----\t-------\t----\t------\t-------\t------\t------\t------\t---------------------")
+	rows := [][]string{
+		{"Name", "Version", "Cate", "Flags", "License", "RPM", "DEB", "PG Ver", "Description"},
+		{"----", "-------", "----", "------", "-------", "------", "------", "------", "---------------------"},
+	}
 	for _, ext := range data {
-		desc := ext.EnDesc
-		if len(desc) > 64 {
-			desc = desc[:64] + "..."
-		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			ext.Name, ext.Version, ext.Category, ext.GetFlag(), ext.License, ext.RpmRepo, ext.DebRepo, CompactVersion(ext.PgVer), desc)
+		rows = append(rows, []string{ext.Name, ext.Version, ext.Category, ext.GetFlag(), ext.License, ext.RpmRepo, ext.DebRepo, CompactVersion(ext.PgVer), ext.ListDesc()})
 	}
-	w.Flush()
+	writeTable(os.Stdout, rows, 2, tableWidth())
 	fmt.Printf("\n(%d Rows) (Flags: b = HasBin, d = HasDDL, s = HasSolib, l = NeedLoad, t = Trusted, r = Relocatable, x = Unknown)\n\n", len(data))
 }
 
+// TabulteTemplate prints extensions one per line with the given template
+func TabulteTemplate(text string, data []*Extension) error {
+	tmpl, err := NewExtensionTemplate(text)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, ext := range data {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, ext); err != nil {
+			return fmt.Errorf("failed to render extension %s: %v", ext.Name, err)
+		}
+		fmt.Println(strings.TrimRight(buf.String(), "\n"))
+	}
+	return nil
+}
+
+// FilterCategory returns extensions belonging to one of given categories (case-insensitive)
+func FilterCategory(exts []*Extension, categories []string) []*Extension {
+	var result []*Extension
+	for _, ext := range exts {
+		for _, cat := range categories {
+			if strings.EqualFold(ext.Category, cat) {
+				result = append(result, ext)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// FilterRequire returns extensions that list one of given extensions in their requires
+func FilterRequire(exts []*Extension, requires []string) []*Extension {
+	var result []*Extension
+	for _, ext := range exts {
+		for _, req := range requires {
+			if slices.Contains(ext.Requires, req) {
+				result = append(result, ext)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// FilterInstalled returns extensions installed on given PostgreSQL
+func FilterInstalled(exts []*Extension, pg *PostgresInstall) []*Extension {
+	var result []*Extension
+	if pg == nil {
+		return result
+	}
+	for _, ext := range exts {
+		if _, ok := pg.ExtensionMap[ext.Name]; ok {
+			result = append(result, ext)
+		}
+	}
+	return result
+}
+
+// FilterPackage returns extensions with (or without, if has is false) a binary package of given type: rpm, deb, or auto for current os
+func FilterPackage(exts []*Extension, pkgType string, has bool) ([]*Extension, error) {
+	if pkgType == "auto" {
+		pkgType = config.OSType
+	}
+	if pkgType != config.DistroEL && pkgType != config.DistroDEB {
+		return nil, fmt.Errorf("invalid package type %q, should be rpm or deb", pkgType)
+	}
+	var result []*Extension
+	for _, ext := range exts {
+		pkg := ext.RpmPkg
+		if pkgType == config.DistroDEB {
+			pkg = ext.DebPkg
+		}
+		if (pkg != "") == has {
+			result = append(result, ext)
+		}
+	}
+	return result, nil
+}
+
 // SearchExtensions performs fuzzy search on extensions
 func SearchExtensions(query string, exts []*Extension) []*Extension {
 	if query == "" {
@@ -161,6 +345,98 @@ func SearchExtensions(query string, exts []*Extension) []*Extension {
 	return extensions
 }
 
+// ScoredExtension is a search result with its relevance score and which field matched
+type ScoredExtension struct {
+	*Extension
+	Score float64 `json:"score"`
+	Match string  `json:"match"` // name, alias, category, description, fuzzy, or empty if no query
+}
+
+// SearchExtensionsRanked scores extensions against query and returns matches sorted by relevance:
+// exact name > exact alias > category > name/alias substring > description substring > fuzzy name/alias
+func SearchExtensionsRanked(query string, exts []*Extension) []ScoredExtension {
+	var results []ScoredExtension
+	query = strings.ToLower(query)
+	for _, ext := range exts {
+		if query == "" {
+			results = append(results, ScoredExtension{Extension: ext})
+			continue
+		}
+		if score, match := searchScore(query, ext); score > 0 {
+			results = append(results, ScoredExtension{Extension: ext, Score: score, Match: match})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// searchScore returns the relevance score of extension to a lower case query, 0 if not matched
+func searchScore(query string, ext *Extension) (float64, string) {
+	name, alias := strings.ToLower(ext.Name), strings.ToLower(ext.Alias)
+	switch {
+	case name == query:
+		return 1.0, "name"
+	case alias == query:
+		return 0.95, "alias"
+	case CategoryMap[query] != "" && ext.Category == CategoryMap[query]:
+		return 0.9, "category"
+	case strings.Contains(name, query):
+		return 0.8, "name"
+	case alias != "" && strings.Contains(alias, query):
+		return 0.75, "alias"
+	case strings.Contains(strings.ToLower(ext.EnDesc), query) || strings.Contains(strings.ToLower(ext.ZhDesc), query):
+		return 0.6, "description"
+	}
+	// fuzzy matches never outrank substring matches
+	score := similarity(query, name)
+	if aliasScore := similarity(query, alias); aliasScore > score {
+		score = aliasScore
+	}
+	if score > 0.3 {
+		return score * 0.5, "fuzzy"
+	}
+	return 0, ""
+}
+
+// InfoCandidates returns extensions for an info lookup: the exact name or alias match,
+// otherwise extensions whose name or alias contains it, ranked by relevance
+func InfoCandidates(name string) []*Extension {
+	if ext, ok := lookupExtension(name); ok {
+		return []*Extension{ext}
+	}
+	var candidates []*Extension
+	for _, r := range SearchExtensionsRanked(name, Catalog.Extensions) {
+		if r.Match == "name" || r.Match == "alias" {
+			candidates = append(candidates, r.Extension)
+		}
+	}
+	return candidates
+}
+
+// PrintSearchJSON prints ranked search results in json, only those still in exts after filtering
+// extensions without a ranked result (e.g. no query given) are appended with zero score
+func PrintSearchJSON(ranked []ScoredExtension, exts []*Extension) error {
+	keep := make(map[*Extension]bool, len(exts))
+	for _, ext := range exts {
+		keep[ext] = true
+	}
+	results := []ScoredExtension{}
+	for _, r := range ranked {
+		if keep[r.Extension] {
+			results = append(results, r)
+			delete(keep, r.Extension)
+		}
+	}
+	for _, ext := range exts {
+		if keep[ext] {
+			results = append(results, ScoredExtension{Extension: ext})
+		}
+	}
+	return utils.PrintJSON(results)
+}
+
 // similarity calculates normalized similarity score between two strings
 func similarity(s1, s2 string) float64 {
 	distance := levenshteinDistance(s1, s2)
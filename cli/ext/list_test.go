@@ -0,0 +1,264 @@
+package ext
+
+import (
+	"bytes"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"strings"
+	"testing"
+)
+
+func TestTabulateVersionAlignment(t *testing.T) {
+	savedOS := config.OSType
+	t.Cleanup(func() { config.OSType = savedOS })
+	config.OSType = config.DistroDEB
+	data := []*Extension{
+		{Name: "alpha", Version: "1.0", Category: "FEAT", DebRepo: "PIGSTY", DebPkg: "postgresql-$v-alpha", DebPg: []string{"17", "16", "15", "14", "13"}},
+		{Name: "beta", Version: "12.3.4-beta", Category: "TYPE", DebRepo: "PGDG", DebPkg: "postgresql-$v-beta", DebPg: []string{"17"}},
+		{Name: "gamma", Version: "0.7", Category: "FUNC"},
+		{Name: "delta", Version: "2", Category: "GIS", DebRepo: "PIGSTY", DebPkg: "postgresql-$v-delta", DebPg: []string{"16", "15"}},
+	}
+
+	var buf bytes.Buffer
+	tabulateVersion(&buf, 16, data, 0, nil)
+	lines := strings.Split(buf.String(), "\n")
+	header := lines[0]
+	verEnd := strings.Index(header, "Version") + len("Version")
+	pkgStart := strings.Index(header, "Package")
+	if verEnd < len("Version") || pkgStart < 0 {
+		t.Fatalf("unexpected header: %q", header)
+	}
+
+	for i, ext := range data {
+		line := lines[i+2]
+		if idx := strings.Index(line, ext.Version); idx+len(ext.Version) != verEnd {
+			t.Errorf("version %q of %s ends at %d, want %d: %q", ext.Version, ext.Name, idx+len(ext.Version), verEnd, line)
+		}
+		avail := ext.Availability(config.OSCode)
+		if pkg := ext.PackageName(16); pkg != "" && !strings.HasPrefix(line[pkgStart:], pkg) {
+			t.Errorf("package column of %s misaligned: %q", ext.Name, line)
+		}
+		if !strings.Contains(line[:pkgStart], avail) {
+			t.Errorf("availability %q of %s not found before package column: %q", avail, ext.Name, line)
+		}
+	}
+}
+
+func TestTabulateVersionPgVersion(t *testing.T) {
+	savedOS := config.OSType
+	t.Cleanup(func() { config.OSType = savedOS })
+	config.OSType = config.DistroDEB
+	data := []*Extension{{Name: "postgis", Version: "3.5.0", DebVer: "3.5.0", DebRepo: "PGDG", DebPkg: "postgresql-$v-postgis-3",
+		DebPg: []string{"17", "16", "12"}, DebPgVer: map[int]string{12: "3.3.7"}}}
+	for pgVer, want := range map[int]string{16: "3.5.0", 12: "3.3.7"} {
+		var buf bytes.Buffer
+		tabulateVersion(&buf, pgVer, data, 0, nil)
+		if row := strings.Split(buf.String(), "\n")[2]; !strings.Contains(row, want) {
+			t.Errorf("version column for pg %d should be %s: %q", pgVer, want, row)
+		}
+	}
+}
+
+func TestFilterRequire(t *testing.T) {
+	data := []*Extension{
+		{Name: "postgis"},
+		{Name: "postgis_raster", Requires: []string{"postgis"}},
+		{Name: "pgrouting", Requires: []string{"postgis", "plpgsql"}},
+		{Name: "vectorscale", Requires: []string{"vector"}},
+	}
+	got := FilterRequire(data, []string{"postgis"})
+	if len(got) != 2 || got[0].Name != "postgis_raster" || got[1].Name != "pgrouting" {
+		t.Errorf("FilterRequire(postgis) = %v", got)
+	}
+	if got := FilterRequire(data, []string{"pg_cron"}); len(got) != 0 {
+		t.Errorf("FilterRequire(pg_cron) = %v, want empty", got)
+	}
+}
+
+func TestTabulateVersionWidth(t *testing.T) {
+	savedOS := config.OSType
+	t.Cleanup(func() { config.OSType = savedOS })
+	config.OSType = config.DistroDEB
+	data := []*Extension{
+		{Name: "pg_short", Version: "1.0", Category: "FEAT", License: "MIT", DebRepo: "PIGSTY", DebPkg: "postgresql-$v-short", DebPg: []string{"17", "16"},
+			EnDesc: "short desc"},
+		{Name: "pg_long_description", Version: "2.1.0", Category: "FUNC", License: "PostgreSQL", DebRepo: "PGDG", DebPkg: "postgresql-$v-long-description", DebPg: []string{"17"},
+			EnDesc: "a very long description that keeps going on and on about what this extension does, far beyond any terminal"},
+	}
+	for _, width := range []int{80, 120, 200} {
+		var buf bytes.Buffer
+		tabulateVersion(&buf, 16, data, width, nil)
+		lines := strings.Split(buf.String(), "\n")
+		descStart := strings.Index(lines[0], "Description")
+		descWidth := max(width-descStart, minDescWidth)
+		for _, line := range lines[2:4] {
+			if n := utils.DisplayWidth(strings.TrimRight(line, " ")); n > descStart+descWidth {
+				t.Errorf("width %d: line has %d columns: %q", width, n, line)
+			}
+		}
+		long := lines[3]
+		fits := descStart+utils.DisplayWidth(data[1].EnDesc) <= width
+		if fits != strings.HasSuffix(long, data[1].EnDesc) || fits == strings.HasSuffix(long, "…") {
+			t.Errorf("width %d: long description should be elided only if it does not fit: %q", width, long)
+		}
+		if !strings.HasSuffix(lines[2], data[0].EnDesc) {
+			t.Errorf("width %d: short description should be kept: %q", width, lines[2])
+		}
+	}
+}
+
+func TestSearchExtensionsRanked(t *testing.T) {
+	exts := []*Extension{
+		{Name: "pgvector_extra", EnDesc: "extra"},
+		{Name: "vchord", EnDesc: "vector search on top of vector"},
+		{Name: "pgvector", Alias: "vector"},
+		{Name: "vector", EnDesc: "vector data type"},
+	}
+	got := SearchExtensionsRanked("vector", exts)
+	want := []string{"vector:name", "pgvector:alias", "pgvector_extra:name", "vchord:description"}
+	if len(got) != len(want) {
+		t.Fatalf("SearchExtensionsRanked() returned %d results, want %d", len(got), len(want))
+	}
+	for i, r := range got {
+		if r.Name+":"+r.Match != want[i] {
+			t.Errorf("result[%d] = %s:%s, want %s", i, r.Name, r.Match, want[i])
+		}
+	}
+	if got := SearchExtensionsRanked("", exts); len(got) != 4 || got[0].Score != 0 {
+		t.Errorf("SearchExtensionsRanked(\"\") should return all extensions unscored")
+	}
+}
+
+func TestInfoCandidates(t *testing.T) {
+	saved := Catalog
+	defer func() { Catalog = saved }()
+	vector, vectorscale := &Extension{Name: "vector", Alias: "pgvector"}, &Extension{Name: "vectorscale"}
+	Catalog = &ExtensionCatalog{
+		Extensions:  []*Extension{vector, vectorscale, {Name: "vchord", EnDesc: "vector search"}},
+		ExtNameMap:  map[string]*Extension{"vector": vector, "vectorscale": vectorscale},
+		ExtAliasMap: map[string]*Extension{"pgvector": vector},
+	}
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"pgvector", 1},
+		{"vectorsc", 1},
+		{"vec", 2}, // description matches are not candidates
+		{"nothing", 0},
+	}
+	for _, tt := range tests {
+		if got := InfoCandidates(tt.name); len(got) != tt.want {
+			t.Errorf("InfoCandidates(%s) returned %d candidates, want %d", tt.name, len(got), tt.want)
+		}
+	}
+}
+
+func TestWriteTableColorAligned(t *testing.T) {
+	rows := [][]string{
+		{"Name", "State", "Description"},
+		{"----", "-----", "-----------"},
+		{"vector", utils.ColorGreen + "[OK]" + utils.ColorReset, "vector type"},
+		{"pg_cron", "[UPD]", "job scheduler"},
+	}
+	var buf bytes.Buffer
+	writeTable(&buf, rows, 2, 0)
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	for i, desc := range []string{"vector type", "job scheduler"} {
+		line := lines[i+2]
+		if prefix := line[:strings.Index(line, desc)]; utils.DisplayWidth(prefix) != len("pg_cron  [UPD]  ") {
+			t.Errorf("description is not aligned: %q", line)
+		}
+	}
+}
+
+func TestWriteTableWideDesc(t *testing.T) {
+	WideDesc = true
+	defer func() { WideDesc = false }()
+	rows := [][]string{
+		{"Name", "Description"},
+		{"----", "-----------"},
+		{"vector", "vector data type and ivfflat and hnsw access methods"},
+	}
+	var buf bytes.Buffer
+	writeTable(&buf, rows, 2, 30)
+	want := "Name    Description\n" +
+		"----    -----------\n" +
+		"vector  vector data type and\n" +
+		"        ivfflat and hnsw\n" +
+		"        access methods\n"
+	if buf.String() != want {
+		t.Errorf("writeTable() with WideDesc =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFilterPackage(t *testing.T) {
+	exts := []*Extension{{Name: "both", RpmPkg: "a", DebPkg: "b"}, {Name: "deb_only", DebPkg: "b"}, {Name: "source"}}
+	if got, _ := FilterPackage(exts, "rpm", true); len(got) != 1 || got[0].Name != "both" {
+		t.Errorf("FilterPackage(rpm, true) = %v", got)
+	}
+	if got, _ := FilterPackage(exts, "deb", false); len(got) != 1 || got[0].Name != "source" {
+		t.Errorf("FilterPackage(deb, false) = %v", got)
+	}
+	if _, err := FilterPackage(exts, "apk", true); err == nil {
+		t.Errorf("FilterPackage(apk) should fail")
+	}
+}
+
+func TestHasRepoPackage(t *testing.T) {
+	savedOS := config.OSType
+	defer func() { config.OSType = savedOS }()
+	config.OSType = config.DistroDEB
+	repo := map[string]bool{"postgresql-16-cron": true, "postgresql-16-pgvector": true}
+	tests := []struct {
+		pkg  string
+		want bool
+	}{
+		{"postgresql-$v-cron", true},
+		{"postgresql-$v-pgvector*", true},
+		{"postgresql-$v-postgis-3", false},
+	}
+	for _, tt := range tests {
+		e := &Extension{Name: "test", DebPkg: tt.pkg}
+		if got := hasRepoPackage(e, 16, repo); got != tt.want {
+			t.Errorf("hasRepoPackage(%s) = %v, want %v", tt.pkg, got, tt.want)
+		}
+	}
+	if hasRepoPackage(&Extension{Name: "test", DebPkg: "postgresql-$v-cron"}, 17, repo) {
+		t.Errorf("hasRepoPackage should not match package of another pg version")
+	}
+}
+
+func TestParseRequiredMinor(t *testing.T) {
+	savedOS := config.OSType
+	t.Cleanup(func() { config.OSType = savedOS })
+
+	config.OSType = config.DistroDEB
+	deb := `Package: postgresql-16-cron
+Version: 1.6.4-1.pgdg22.04+1
+Depends: postgresql-16 (>= 16.2), libc6 (>= 2.34)
+
+Package: postgresql-16-pgvector
+Depends: postgresql-16, libc6 (>= 2.14)
+Description: needs postgresql-16 (>= 16.9) in description only
+`
+	got := parseRequiredMinor(deb, 16)
+	if len(got) != 1 || got["postgresql-16-cron"] != 2 {
+		t.Errorf("parseRequiredMinor(deb) = %v", got)
+	}
+
+	config.OSType = config.DistroEL
+	el := `@pg_cron_16
+postgresql16-server >= 16.3
+libc.so.6()(64bit)
+@pgvector_16
+postgresql16-server
+`
+	got = parseRequiredMinor(el, 16)
+	if len(got) != 1 || got["pg_cron_16"] != 3 {
+		t.Errorf("parseRequiredMinor(el) = %v", got)
+	}
+	if got = parseRequiredMinor(el, 17); len(got) != 0 {
+		t.Errorf("parseRequiredMinor(el, 17) = %v", got)
+	}
+}
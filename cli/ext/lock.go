@@ -0,0 +1,106 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"pig/internal/utils"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LockFilePath is the pig-level lock file used to serialize install/remove/update
+var LockFilePath = "/var/run/pig.lock"
+
+var (
+	LockNoWait  bool                            // fail fast if another pig is holding the lock
+	LockTimeout time.Duration = time.Minute * 5 // max time to wait for the lock
+)
+
+// AcquireLock acquires the pig-level exclusive lock, and returns a function to release it
+// if another pig holds the lock, wait until timeout, or fail immediately with LockNoWait
+func AcquireLock() (func(), error) {
+	f, err := openLockFile()
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(LockTimeout)
+	waiting := false
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if err != syscall.EWOULDBLOCK {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %v", f.Name(), err)
+		}
+		holder := lockHolder(f.Name())
+		if LockNoWait {
+			f.Close()
+			return nil, fmt.Errorf("another pig (pid %s) is running, lock file %s", holder, f.Name())
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timeout after %s waiting for another pig (pid %s), lock file %s", LockTimeout, holder, f.Name())
+		}
+		if !waiting {
+			logrus.Warnf("another pig (pid %s) is running, waiting for lock %s (timeout %s)", holder, f.Name(), LockTimeout)
+			waiting = true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	// record the holder pid for other pig processes
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	logrus.Debugf("acquired pig lock %s", f.Name())
+	return func() {
+		_ = f.Truncate(0)
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+		logrus.Debugf("released pig lock %s", f.Name())
+	}, nil
+}
+
+// openLockFile opens the lock file, a missing lock file is created with sudo (mode 0666, so every user shares it)
+// as /var/run is root-only and wiped at boot, an existing lock file is opened read-only if not writable
+// there is no fallback location, as users taking different lock files would not be serialized
+func openLockFile() (*os.File, error) {
+	f, err := os.OpenFile(LockFilePath, os.O_RDWR|os.O_CREATE, 0666)
+	if err == nil {
+		return f, nil
+	}
+	if !os.IsPermission(err) {
+		return nil, fmt.Errorf("failed to open lock file %s: %v", LockFilePath, err)
+	}
+	if _, statErr := os.Stat(LockFilePath); os.IsNotExist(statErr) {
+		logrus.Debugf("create lock file %s with sudo", LockFilePath)
+		if err := utils.SudoCommand([]string{"touch", LockFilePath}); err != nil {
+			return nil, fmt.Errorf("can not create lock file %s: %v", LockFilePath, err)
+		}
+		if err := utils.SudoCommand([]string{"chmod", "0666", LockFilePath}); err != nil {
+			logrus.Debugf("failed to chmod lock file %s: %v", LockFilePath, err)
+		}
+		if f, err := os.OpenFile(LockFilePath, os.O_RDWR, 0); err == nil {
+			return f, nil
+		}
+	}
+	// flock works on read-only descriptors, only the holder pid could not be recorded
+	if f, roErr := os.Open(LockFilePath); roErr == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("can not open lock file %s: %v", LockFilePath, err)
+}
+
+// lockHolder returns the pid recorded in the lock file
+func lockHolder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil || strings.TrimSpace(string(data)) == "" {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,33 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"pig/internal/utils"
+	"strings"
+	"time"
+)
+
+// LogFile is the path to append full package manager output of install, disabled if empty
+var LogFile string
+
+// OpenLogFile starts copying package manager output into LogFile, the returned function stops it
+func OpenLogFile() (func(), error) {
+	if LogFile == "" {
+		return func() {}, nil
+	}
+	f, err := os.OpenFile(LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file %s: %v", LogFile, err)
+	}
+	fmt.Fprintf(f, "# %s pig %s\n", time.Now().Format(time.RFC3339), strings.Join(os.Args[1:], " "))
+	utils.CommandLog = f
+	return func() {
+		utils.CommandLog = nil
+		if err := f.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write log file %s: %v\n", LogFile, err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "package manager output logged to %s\n", LogFile)
+	}, nil
+}
@@ -0,0 +1,33 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"pig/internal/utils"
+	"strings"
+	"testing"
+)
+
+func TestOpenLogFile(t *testing.T) {
+	LogFile = filepath.Join(t.TempDir(), "install.log")
+	defer func() { LogFile = "" }()
+	for i := 0; i < 2; i++ {
+		closeLog, err := OpenLogFile()
+		if err != nil {
+			t.Fatalf("OpenLogFile() error = %v", err)
+		}
+		fmt.Fprintf(utils.CommandLog, "run %d\n", i)
+		closeLog()
+		if utils.CommandLog != nil {
+			t.Fatalf("CommandLog should be reset after close")
+		}
+	}
+	data, err := os.ReadFile(LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "run 0\n") || !strings.Contains(string(data), "run 1\n") {
+		t.Errorf("log file should be appended, got %q", data)
+	}
+}
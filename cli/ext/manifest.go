@@ -0,0 +1,114 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is a declarative list of extensions to be installed, entries are name or name=version as in install
+//
+//	pg_versions: [16, 17]   # optional, detected or latest PostgreSQL if empty
+//	extensions:
+//	  - postgis
+//	  - pg_cron=1.6.4
+type Manifest struct {
+	PgVersions []int    `yaml:"pg_versions"`
+	Extensions []string `yaml:"extensions"`
+}
+
+// LoadManifest reads and parses a manifest file, unknown fields are rejected to catch typos
+func LoadManifest(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var m Manifest
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %v", path, err)
+	}
+	return &m, nil
+}
+
+// Validate checks manifest entries against catalog and returns all problems found
+// pgVer is used if the manifest does not list pg versions
+func (m *Manifest) Validate(pgVer int) []string {
+	var problems []string
+	pgVers := m.PgVersions
+	if len(pgVers) == 0 {
+		pgVers = []int{pgVer}
+	}
+	for _, v := range pgVers {
+		if err := Catalog.CheckPgMajor(v); err != nil {
+			problems = append(problems, fmt.Sprintf("pg_versions: %v", err))
+		}
+	}
+	if len(m.Extensions) == 0 {
+		problems = append(problems, "extensions: no extension listed")
+	}
+	seen := make(map[string]int)
+	for i, entry := range m.Extensions {
+		where := fmt.Sprintf("extensions[%d] %s", i, entry)
+		name, version, _ := strings.Cut(entry, "=")
+		ext, ok := lookupExtension(name)
+		if !ok {
+			problems = append(problems, where+": unknown extension")
+			continue
+		}
+		if j, dup := seen[ext.Name]; dup {
+			problems = append(problems, fmt.Sprintf("%s: duplicate of extensions[%d]", where, j))
+		}
+		seen[ext.Name] = i
+		for _, v := range pgVers {
+			if !ext.Available(v) {
+				problems = append(problems, fmt.Sprintf("%s: not available for PostgreSQL %d", where, v))
+			} else if version != "" && !versionAvailable(ext, version, v) {
+				problems = append(problems, fmt.Sprintf("%s: version %s not found for PostgreSQL %d (catalog: %s)", where, version, v, ext.PgPackageVersion(v)))
+			}
+		}
+	}
+	return problems
+}
+
+// versionAvailable reports whether the version is the catalog version, or available in repos for given pg version
+func versionAvailable(ext *Extension, version string, pgVer int) bool {
+	if version == ext.PgPackageVersion(pgVer) {
+		return true
+	}
+	pkgs := processPkgName(ext.PackageName(pgVer), pgVer)
+	if len(pkgs) == 0 {
+		return false
+	}
+	for _, r := range queryRepoVersions(pkgs[0]) {
+		v := r.Version
+		if _, after, ok := strings.Cut(v, ":"); ok {
+			v = after // strip epoch
+		}
+		if v == version || strings.HasPrefix(v, version+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateManifest loads and validates a manifest, all problems are printed and an error is returned if any
+func ValidateManifest(path string, pgVer int) error {
+	m, err := LoadManifest(path)
+	if err != nil {
+		return err
+	}
+	problems := m.Validate(pgVer)
+	for _, p := range problems {
+		fmt.Printf("%s: %s\n", path, p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found in manifest %s", len(problems), path)
+	}
+	fmt.Printf("%s: %d extensions ok\n", path, len(m.Extensions))
+	return nil
+}
@@ -0,0 +1,50 @@
+package ext
+
+import (
+	"os"
+	"path/filepath"
+	"pig/internal/config"
+	"strings"
+	"testing"
+)
+
+func TestManifestValidate(t *testing.T) {
+	saved, savedOS := Catalog, config.OSType
+	defer func() { Catalog, config.OSType = saved, savedOS }()
+	config.OSType = "" // skip repo version queries
+	postgis := &Extension{Name: "postgis", Version: "3.5.2", PgVer: []string{"16", "17"}}
+	Catalog = &ExtensionCatalog{Extensions: []*Extension{postgis}, ExtNameMap: map[string]*Extension{"postgis": postgis}}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.yml")
+	data := "pg_versions: [16, 12]\nextensions:\n  - postgis=3.5.2\n  - nothing\n  - postgis=9.9\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	problems := m.Validate(17)
+	want := []string{
+		"pg_versions: unknown PostgreSQL major version 12",
+		"extensions[1] nothing: unknown extension",
+		"extensions[2] postgis=9.9: duplicate of extensions[0]",
+		"extensions[2] postgis=9.9: version 9.9 not found for PostgreSQL 16",
+	}
+	if len(problems) != len(want)+1 { // version 9.9 is also checked against pg 12
+		t.Fatalf("Validate() = %q", problems)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(problems[i], w) {
+			t.Errorf("problem %d = %q, want prefix %q", i, problems[i], w)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("extension:\n  - postgis\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadManifest(path); err == nil {
+		t.Errorf("LoadManifest() should reject unknown field")
+	}
+}
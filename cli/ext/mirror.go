@@ -0,0 +1,203 @@
+package ext
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"pig/internal/config"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MirrorPackages returns all packages referenced by the catalog for given PostgreSQL major version
+func MirrorPackages(pgVer int) []string {
+	var pkgNames []string
+	ver := strconv.Itoa(pgVer)
+	for _, ext := range Catalog.Extensions {
+		if ext.Repo == "CONTRIB" {
+			continue
+		}
+		var avail []string
+		switch config.OSType {
+		case config.DistroEL:
+			avail = ext.RpmPg
+		case config.DistroDEB:
+			avail = ext.DebPg
+		}
+		if !slices.Contains(avail, ver) {
+			continue
+		}
+		pkgNames = append(pkgNames, processPkgName(ext.PackageName(pgVer), pgVer)...)
+	}
+	return dedupPkgNames(pkgNames)
+}
+
+// MirrorExtensions downloads packages of all catalog extensions into a repo directory
+// existing packages are skipped, so an interrupted mirror can be resumed by running it again
+func MirrorExtensions(pgVer int, arch, dir string, jobs int, index bool) error {
+	if pgVer == 0 {
+		pgVer = PostgresLatestMajorVersion
+	}
+	if arch == "" {
+		arch = config.OSArch
+	}
+	if config.OSType != config.DistroEL && config.OSType != config.DistroDEB {
+		return fmt.Errorf("unsupported OS type: %s", config.OSType)
+	}
+	arch = normalizeArch(arch)
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("invalid mirror dir: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create mirror dir %s: %v", dir, err)
+	}
+
+	var pending []string
+	pkgNames := MirrorPackages(pgVer)
+	for _, pkg := range pkgNames {
+		if findCached(dir, pkg, arch, "") != "" {
+			logrus.Debugf("skip mirrored package %s", pkg)
+			continue
+		}
+		pending = append(pending, pkg)
+	}
+	logrus.Infof("mirror %d packages for PostgreSQL %d (%s) into %s, %d already exist", len(pkgNames), pgVer, arch, dir, len(pkgNames)-len(pending))
+
+	failed := downloadPackages(dir, pending, arch, jobs)
+	if index {
+		if err := indexMirror(dir); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d packages failed to download: %s", len(failed), strings.Join(failed, ", "))
+	}
+	logrus.Infof("mirror complete: %d packages downloaded, %d skipped", len(pending), len(pkgNames)-len(pending))
+	return nil
+}
+
+// normalizeArch converts arch name to the convention of current package manager
+func normalizeArch(arch string) string {
+	switch config.OSType {
+	case config.DistroEL:
+		switch arch {
+		case "amd64":
+			return "x86_64"
+		case "arm64":
+			return "aarch64"
+		}
+	case config.DistroDEB:
+		switch arch {
+		case "x86_64":
+			return "amd64"
+		case "aarch64":
+			return "arm64"
+		}
+	}
+	return arch
+}
+
+// downloadPackages downloads packages into dir with given concurrency, returns sorted names of failed packages
+func downloadPackages(dir string, pkgs []string, arch string, jobs int) []string {
+	if jobs < 1 {
+		jobs = 1
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var failed []string
+	queue := make(chan string)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pkg := range queue {
+				if err := downloadPackage(dir, pkg, arch); err != nil {
+					logrus.Warnf("failed to download %s: %v", pkg, err)
+					mu.Lock()
+					failed = append(failed, pkg)
+					mu.Unlock()
+					continue
+				}
+				logrus.Infof("downloaded %s", pkg)
+			}
+		}()
+	}
+	for _, pkg := range pkgs {
+		queue <- pkg
+	}
+	close(queue)
+	wg.Wait()
+	slices.Sort(failed)
+	return failed
+}
+
+// downloadPackage downloads a single package into dir with package manager
+// files are downloaded into a private staging dir first, so a failed download never leaves partial files in dir
+func downloadPackage(dir, pkg, arch string) error {
+	staging, err := os.MkdirTemp(dir, ".download-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging dir: %v", err)
+	}
+	defer os.RemoveAll(staging)
+
+	var cmd *exec.Cmd
+	if config.OSType == config.DistroEL {
+		cmd = exec.Command("dnf", "download", "--forcearch="+arch, "--destdir="+staging, pkg)
+	} else {
+		// apt takes a pinned version after the arch qualifier: name:arch=version
+		name, version, pinned := strings.Cut(pkg, "=")
+		target := name + ":" + arch
+		if pinned {
+			target += "=" + version
+		}
+		cmd = exec.Command("apt-get", "download", target)
+	}
+	cmd.Dir = staging
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	entries, err := os.ReadDir(staging)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := os.Rename(filepath.Join(staging, entry.Name()), filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to move %s into %s: %v", entry.Name(), dir, err)
+		}
+	}
+	return nil
+}
+
+// indexMirror generates repo metadata with createrepo_c or dpkg-scanpackages
+func indexMirror(dir string) error {
+	var cmd *exec.Cmd
+	if config.OSType == config.DistroEL {
+		createrepo := "createrepo_c"
+		if _, err := exec.LookPath(createrepo); err != nil {
+			createrepo = "createrepo"
+		}
+		cmd = exec.Command(createrepo, dir)
+	} else {
+		cmd = exec.Command("sh", "-c", "dpkg-scanpackages . /dev/null | gzip -9c > Packages.gz")
+		cmd.Dir = dir
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	logrus.Infof("generating repo index: %s", strings.Join(cmd.Args, " "))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to generate repo index: %v", err)
+	}
+	return nil
+}
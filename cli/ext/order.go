@@ -0,0 +1,83 @@
+package ext
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DependencyOrder installs and creates requested extensions after the requested extensions they require
+var DependencyOrder bool
+
+// SortByDependency sorts extensions so that required extensions come before their dependents,
+// only requires among the given extensions are considered, and the input order is kept otherwise
+func SortByDependency(exts []*Extension) ([]*Extension, error) {
+	byName := make(map[string]*Extension, len(exts))
+	for _, ext := range exts {
+		byName[ext.Name] = ext
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(exts))
+	sorted := make([]*Extension, 0, len(exts))
+	var path []string
+	var visit func(ext *Extension) error
+	visit = func(ext *Extension) error {
+		switch state[ext.Name] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i, name := range path {
+				if name == ext.Name {
+					start = i
+				}
+			}
+			return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(path[start:], " -> "), ext.Name)
+		}
+		state[ext.Name] = visiting
+		path = append(path, ext.Name)
+		for _, req := range ext.Requires {
+			if dep, ok := byName[req]; ok {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[ext.Name] = visited
+		sorted = append(sorted, ext)
+		return nil
+	}
+	for _, ext := range exts {
+		if err := visit(ext); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// sortUnits reorders install units by the dependency order of exts, non-extension units (e.g. kernel aliases) go first
+func sortUnits(units []*InstallUnit, exts []*Extension) []*InstallUnit {
+	rank := make(map[string]int, len(exts))
+	for i, ext := range exts {
+		rank[ext.Name] = i + 1
+	}
+	sorted := make([]*InstallUnit, 0, len(units))
+	for _, unit := range units {
+		if rank[unit.Name] == 0 {
+			sorted = append(sorted, unit)
+		}
+	}
+	byName := make(map[string]*InstallUnit, len(units))
+	for _, unit := range units {
+		byName[unit.Name] = unit
+	}
+	for _, ext := range exts {
+		if unit, ok := byName[ext.Name]; ok {
+			sorted = append(sorted, unit)
+		}
+	}
+	return sorted
+}
@@ -0,0 +1,36 @@
+package ext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortByDependency(t *testing.T) {
+	exts := []*Extension{
+		{Name: "pgrouting", Requires: []string{"postgis", "plpgsql"}},
+		{Name: "vector"},
+		{Name: "postgis_raster", Requires: []string{"postgis"}},
+		{Name: "postgis"},
+	}
+	sorted, err := SortByDependency(exts)
+	if err != nil {
+		t.Fatalf("SortByDependency() error = %v", err)
+	}
+	var names []string
+	for _, ext := range sorted {
+		names = append(names, ext.Name)
+	}
+	if got, want := strings.Join(names, ","), "postgis,pgrouting,vector,postgis_raster"; got != want {
+		t.Errorf("SortByDependency() = %s, want %s", got, want)
+	}
+
+	units := sortUnits([]*InstallUnit{{Name: "pgrouting"}, {Name: "pg17"}, {Name: "postgis"}}, sorted)
+	if units[0].Name != "pg17" || units[1].Name != "postgis" || units[2].Name != "pgrouting" {
+		t.Errorf("sortUnits() = %s, %s, %s", units[0].Name, units[1].Name, units[2].Name)
+	}
+
+	cycle := []*Extension{{Name: "a", Requires: []string{"b"}}, {Name: "b", Requires: []string{"c"}}, {Name: "c", Requires: []string{"b"}}}
+	if _, err := SortByDependency(cycle); err == nil || !strings.Contains(err.Error(), "b -> c -> b") {
+		t.Errorf("SortByDependency() error = %v, want cycle b -> c -> b", err)
+	}
+}
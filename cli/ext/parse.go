@@ -12,8 +12,8 @@ import (
 
 // ParseExtension parses a CSV record into an Extension struct
 func ParseExtension(record []string) (*Extension, error) {
-	if len(record) != 34 {
-		return nil, fmt.Errorf("invalid record length: got %d, want 34", len(record))
+	if len(record) < 34 || len(record) > 41 {
+		return nil, fmt.Errorf("invalid record length: got %d, want 34 to 41", len(record))
 	}
 
 	id, err := strconv.Atoi(record[0])
@@ -63,9 +63,67 @@ func ParseExtension(record []string) (*Extension, error) {
 		Comment:     strings.TrimSpace(record[33]),
 	}
 
+	// optional config column: postgresql.conf settings separated by semicolon
+	if len(record) >= 35 {
+		for _, item := range strings.Split(record[34], ";") {
+			if item = strings.TrimSpace(item); item != "" {
+				ext.Config = append(ext.Config, item)
+			}
+		}
+	}
+
+	// optional source column: source repository url
+	if len(record) >= 36 {
+		ext.Source = strings.TrimSpace(record[35])
+	}
+
+	// optional provides column: virtual names provided by this extension
+	if len(record) >= 37 {
+		ext.Provides = splitAndTrim(record[36])
+	}
+
+	// optional deprecated & replacement columns: abandoned extension and its maintained alternative
+	if len(record) >= 38 {
+		ext.Deprecated = parseBool(record[37])
+	}
+	if len(record) >= 39 {
+		ext.Replacement = strings.TrimSpace(record[38])
+		ext.Deprecated = ext.Deprecated || ext.Replacement != ""
+	}
+
+	// optional rpm & deb per pg version columns: versions of older PG majors that lag behind, e.g. {12:3.3.7,13:3.4.4}
+	if len(record) >= 40 {
+		if ext.RpmPgVer, err = parsePgVersions(record[39]); err != nil {
+			return nil, fmt.Errorf("invalid rpm_pg_ver: %v", err)
+		}
+	}
+	if len(record) == 41 {
+		if ext.DebPgVer, err = parsePgVersions(record[40]); err != nil {
+			return nil, fmt.Errorf("invalid deb_pg_ver: %v", err)
+		}
+	}
+
 	return ext, nil
 }
 
+// parsePgVersions parses pg major to version pairs like {12:3.3.7,13:3.4.4}, nil is returned if empty
+func parsePgVersions(s string) (map[int]string, error) {
+	items := splitAndTrim(s)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	versions := make(map[int]string, len(items))
+	for _, item := range items {
+		major, version, ok := strings.Cut(item, ":")
+		pgVer, err := strconv.Atoi(strings.TrimSpace(major))
+		if !ok || err != nil || strings.TrimSpace(version) == "" {
+			return nil, fmt.Errorf("invalid entry %q, should be major:version", item)
+		}
+		versions[pgVer] = strings.TrimSpace(version)
+	}
+	return versions, nil
+}
+
 // splitAndTrim splits a comma-separated string and trims whitespace
 // used as auxiliary function for parsing extension data
 func splitAndTrim(s string) []string {
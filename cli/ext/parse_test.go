@@ -109,6 +109,58 @@ func TestParseExtension(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "optional config column",
+			record: []string{
+				"1001", "cfg", "cfg", "", "", "", "", "", "", "", "f", "f", "t", "t", "t", "", "", "", "", "",
+				"", "", "", "", "", "", "", "", "", "", "", "", "", "",
+				"shared_preload_libraries = 'cfg'; cfg.workers = 4 ;",
+			},
+			want: &Extension{
+				ID:       1001,
+				Name:     "cfg",
+				Alias:    "cfg",
+				Tags:     []string{},
+				HasSolib: true,
+				NeedDDL:  true,
+				NeedLoad: true,
+				Schemas:  []string{},
+				PgVer:    []string{},
+				Requires: []string{},
+				RpmPg:    []string{},
+				RpmDeps:  []string{},
+				DebDeps:  []string{},
+				DebPg:    []string{},
+				BadCase:  []string{},
+				Config:   []string{"shared_preload_libraries = 'cfg'", "cfg.workers = 4"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "optional deprecated and replacement columns",
+			record: []string{
+				"1002", "old", "old", "", "", "", "", "", "", "", "f", "f", "f", "f", "f", "", "", "", "", "",
+				"", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "", "new_ext",
+			},
+			want: &Extension{
+				ID:          1002,
+				Name:        "old",
+				Alias:       "old",
+				Tags:        []string{},
+				Schemas:     []string{},
+				PgVer:       []string{},
+				Requires:    []string{},
+				RpmPg:       []string{},
+				RpmDeps:     []string{},
+				DebDeps:     []string{},
+				DebPg:       []string{},
+				BadCase:     []string{},
+				Provides:    []string{},
+				Deprecated:  true,
+				Replacement: "new_ext",
+			},
+			wantErr: false,
+		},
 		{
 			name:    "invalid record length",
 			record:  []string{"1", "test"},
@@ -236,3 +288,18 @@ func TestSplitAndTrim(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePgVersions(t *testing.T) {
+	got, err := parsePgVersions("{12:3.3.7, 13:3.4.4}")
+	if err != nil || !reflect.DeepEqual(got, map[int]string{12: "3.3.7", 13: "3.4.4"}) {
+		t.Errorf("parsePgVersions() = %v, %v", got, err)
+	}
+	if got, err := parsePgVersions(""); got != nil || err != nil {
+		t.Errorf("parsePgVersions(empty) = %v, %v, want nil", got, err)
+	}
+	for _, bad := range []string{"{3.3.7}", "{pg12:3.3.7}", "{12:}"} {
+		if _, err := parsePgVersions(bad); err == nil {
+			t.Errorf("parsePgVersions(%q) should fail", bad)
+		}
+	}
+}
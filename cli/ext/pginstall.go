@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"pig/internal/config"
 	"pig/internal/utils"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -44,8 +46,19 @@ var (
 	PostgresElSearchPath        = []string{"/usr/pgsql-%s/bin/pg_config"}
 	PostgresDEBSearchPath       = []string{"/usr/lib/postgresql/%s/bin/pg_config"}
 	PostgresMACSearchPath       = []string{"/opt/homebrew/opt/postgresql@%s/bin/pg_config"}
+	PostgresExtraSearchRoot     []string // extra search roots, e.g. /opt/pg/%s or /opt/pg/16
 )
 
+// AddSearchRoots adds extra PostgreSQL search roots (dir contains bin/pg_config), '%s' is replaced with major version
+func AddSearchRoots(roots ...string) {
+	for _, root := range roots {
+		root = strings.TrimSuffix(strings.TrimSpace(root), "/")
+		if root != "" && !slices.Contains(PostgresExtraSearchRoot, root) {
+			PostgresExtraSearchRoot = append(PostgresExtraSearchRoot, root)
+		}
+	}
+}
+
 // NewPostgresInstall hold the information of a PostgreSQL installation
 func NewPostgresInstall(pgConfigPath string) (*PostgresInstall, error) {
 	pi := &PostgresInstall{PgConfig: pgConfigPath}
@@ -146,6 +159,60 @@ func GetActivePgConfig() (string, error) {
 	return absPgConfigPath, nil
 }
 
+// PathPostgresVersions returns major version -> pg_config path of all distinct pg_config found in PATH
+func PathPostgresVersions() map[int]string {
+	versions := make(map[int]string)
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		pgConfig := filepath.Join(dir, "pg_config")
+		info, err := os.Stat(pgConfig)
+		if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+			continue
+		}
+		realPath, err := filepath.EvalSymlinks(pgConfig)
+		if err != nil || seen[realPath] {
+			continue
+		}
+		seen[realPath] = true
+		output, err := exec.Command(pgConfig, "--version").Output()
+		if err != nil {
+			logrus.Debugf("failed to get version from %s: %v", pgConfig, err)
+			continue
+		}
+		major, _, err := ParsePostgresVersion(strings.TrimSpace(string(output)))
+		if err != nil {
+			logrus.Debugf("failed to parse version from %s: %v", pgConfig, err)
+			continue
+		}
+		if _, exists := versions[major]; !exists {
+			versions[major] = pgConfig
+		}
+	}
+	return versions
+}
+
+// WarnMixedPathVersions warns if multiple PostgreSQL major versions are found in PATH
+func WarnMixedPathVersions(active *PostgresInstall) {
+	versions := PathPostgresVersions()
+	if len(versions) < 2 {
+		return
+	}
+	var majors []int
+	for v := range versions {
+		majors = append(majors, v)
+	}
+	slices.Sort(majors)
+	var found []string
+	for _, v := range majors {
+		found = append(found, fmt.Sprintf("%d (%s)", v, versions[v]))
+	}
+	using := "none"
+	if active != nil {
+		using = strconv.Itoa(active.MajorVersion)
+	}
+	logrus.Warnf("multiple PostgreSQL major versions found in PATH: %s, using %s, specify -v or --path to disambiguate", strings.Join(found, ", "), using)
+}
+
 // GetActivePostgresInstall returns the active PostgreSQL installation
 func GetActivePostgresInstall() (*PostgresInstall, error) {
 	pgConfigPath, err := GetActivePgConfig()
@@ -155,8 +222,35 @@ func GetActivePostgresInstall() (*PostgresInstall, error) {
 	return NewPostgresInstall(pgConfigPath)
 }
 
-// DetectPostgres detects all installed PostgreSQL versions on the system
+// DetectPostgres detects all installed PostgreSQL versions and keeps them in package globals
+// It is a compat shim around FindPostgres, repeated calls are no-op once detected
 func DetectPostgres() error {
+	if Inited {
+		return nil
+	}
+	active, all, err := FindPostgres()
+	if err != nil {
+		return err
+	}
+	SetPostgres(active, all)
+	return nil
+}
+
+// SetPostgres sets the active and all installed PostgreSQL as package globals
+func SetPostgres(active *PostgresInstall, all []*PostgresInstall) {
+	Active = active
+	Installs = make(map[int]*PostgresInstall, len(all))
+	PathMap = make(map[string]*PostgresInstall, len(all))
+	for _, pi := range all {
+		Installs[pi.MajorVersion] = pi
+		PathMap[pi.PgConfigPath] = pi
+	}
+	Inited = true
+}
+
+// FindPostgres detects the active and all installed PostgreSQL (sorted by major version desc) without touching globals
+func FindPostgres() (*PostgresInstall, []*PostgresInstall, error) {
+	var active *PostgresInstall
 	allPostgres := make(map[int]*PostgresInstall)
 	var searchPath []string
 
@@ -169,7 +263,19 @@ func DetectPostgres() error {
 	case config.DistroMAC:
 		searchPath = PostgresMACSearchPath
 	default:
-		return fmt.Errorf("unsupported OS type: %v", config.OSType)
+		return nil, nil, fmt.Errorf("unsupported OS type: %v", config.OSType)
+	}
+
+	// merge extra search roots with built-in defaults, roots without version placeholder are checked later
+	var fixedPaths []string
+	searchPath = slices.Clone(searchPath)
+	for _, root := range PostgresExtraSearchRoot {
+		pattern := filepath.Join(root, "bin", "pg_config")
+		if !strings.Contains(root, "%s") {
+			fixedPaths = append(fixedPaths, pattern)
+		} else if !slices.Contains(searchPath, pattern) {
+			searchPath = append(searchPath, pattern)
+		}
 	}
 
 	// Get the active pg_config path
@@ -187,7 +293,7 @@ func DetectPostgres() error {
 
 	// Iterate over possible PostgreSQL major versions
 	for _, v := range PostgresActiveMajorVersions {
-		if _, exists := Installs[v]; exists {
+		if _, exists := allPostgres[v]; exists {
 			continue
 		}
 
@@ -207,7 +313,7 @@ func DetectPostgres() error {
 			}
 			if activePhysicalPath != "" && pi.PgConfigPath == activePhysicalPath {
 				logrus.Debugf("found active PostgreSQL %d at %s", pi.MajorVersion, pgConfigPath)
-				Active = pi
+				active = pi
 			} else {
 				logrus.Debugf("found PostgreSQL %d at %s", v, pgConfigPath)
 			}
@@ -215,80 +321,86 @@ func DetectPostgres() error {
 		}
 	}
 
+	// Check extra search roots without version placeholder
+	for _, pgConfigPath := range fixedPaths {
+		if _, err := os.Stat(pgConfigPath); err != nil {
+			continue
+		}
+		pi, err := NewPostgresInstall(pgConfigPath)
+		if err != nil {
+			logrus.Debugf("failed to detect PostgreSQL at %s: %v", pgConfigPath, err)
+			continue
+		}
+		if _, exists := allPostgres[pi.MajorVersion]; exists {
+			logrus.Debugf("PostgreSQL %d already found, skip %s", pi.MajorVersion, pgConfigPath)
+			continue
+		}
+		if activePhysicalPath != "" && pi.PgConfigPath == activePhysicalPath {
+			active = pi
+		}
+		logrus.Debugf("found PostgreSQL %d at %s", pi.MajorVersion, pgConfigPath)
+		allPostgres[pi.MajorVersion] = pi
+	}
+
 	// If active is not found by iteration, try to find it by pg_config path
-	if Active == nil && activePhysicalPath != "" {
-		Active, err = NewPostgresInstall(activePhysicalPath)
+	if active == nil && activePhysicalPath != "" {
+		active, err = NewPostgresInstall(activePhysicalPath)
 		if err != nil {
 			logrus.Debugf("failed to detect active PostgreSQL: %v", err)
 		}
 	}
 
-	Installs = allPostgres
-	PathMap = make(map[string]*PostgresInstall)
+	all := make([]*PostgresInstall, 0, len(allPostgres))
 	for _, pi := range allPostgres {
-		PathMap[pi.PgConfigPath] = pi
+		all = append(all, pi)
 	}
-	Inited = true
-	return nil
+	sort.Slice(all, func(i, j int) bool { return all[i].MajorVersion > all[j].MajorVersion })
+	return active, all, nil
 }
 
-// PostgresInstallSummary print the summary of PostgreSQL installation
-func PostgresInstallSummary() {
-	if !Inited {
-		if err := DetectPostgres(); err != nil {
-			logrus.Errorf("failed to detect PostgreSQL: %v", err)
-			return
-		}
+// Detection holds the result of a PostgreSQL detection, the caller owns it
+type Detection struct {
+	Active   *PostgresInstall   // the active PostgreSQL installation (in PATH)
+	Installs []*PostgresInstall // all installed PostgreSQL, sorted by major version desc
+}
+
+// NewDetection detects all installed PostgreSQL and returns the result without touching globals
+func NewDetection() (*Detection, error) {
+	active, all, err := FindPostgres()
+	if err != nil {
+		return nil, err
 	}
-	// print installed PostgreSQL versions using tabwriter
-	writer := new(tabwriter.Writer)
-	writer.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	return &Detection{Active: active, Installs: all}, nil
+}
 
-	if len(Installs) > 0 {
-		fmt.Fprintln(writer, "Installed:")
-		for _, v := range Installs {
-			if v == Active {
-				fmt.Fprintf(writer, "* %s\t%s\n", v.Version, fmt.Sprintf("%-3d Extensions", len(v.Extensions)))
-			}
-		}
-		for _, v := range Installs {
-			if v != Active {
-				fmt.Fprintf(writer, "- %s\t%s\n", v.Version, fmt.Sprintf("%-3d Extensions", len(v.Extensions)))
-			}
-		}
-	} else {
-		fmt.Fprintln(writer, "No PostgreSQL installation found")
+// globalDetection wraps the package globals set by SetPostgres as a Detection
+func globalDetection() *Detection {
+	d := &Detection{Active: Active}
+	for _, pi := range Installs {
+		d.Installs = append(d.Installs, pi)
 	}
+	sort.Slice(d.Installs, func(i, j int) bool { return d.Installs[i].MajorVersion > d.Installs[j].MajorVersion })
+	return d
+}
 
-	// print active PostgreSQL detail using tabwriter
-	if Active != nil {
-		fmt.Fprintln(writer, "\nActive:")
-		fmt.Fprintf(writer, "PG Version\t:  %s\n", Active.Version)
-		fmt.Fprintf(writer, "Config Path\t:  %s\n", Active.PgConfig)
-		fmt.Fprintf(writer, "Binary Path\t:  %s\n", Active.BinPath)
-		fmt.Fprintf(writer, "Library Path\t:  %s\n", Active.LibPath)
-		fmt.Fprintf(writer, "Extension Path\t:  %s\n", Active.ExtPath)
-	} else {
-		fmt.Fprintln(writer, "\nNo active PostgreSQL found in PATH:")
-		// split the PATH and print each path
-		paths := strings.Split(os.Getenv("PATH"), ":")
-		for _, path := range paths {
-			fmt.Fprintf(writer, "- %s\n", path)
+// Major returns the detected PostgreSQL of the given major version, or nil
+func (d *Detection) Major(major int) *PostgresInstall {
+	for _, pi := range d.Installs {
+		if pi.MajorVersion == major {
+			return pi
 		}
 	}
-
-	writer.Flush()
+	return nil
 }
 
-func GetPostgres(args ...string) (pi *PostgresInstall, err error) {
+// Select picks a PostgreSQL by pg_config path or version, the active one if no arg is given
+func (d *Detection) Select(args ...string) (*PostgresInstall, error) {
 	// you can give at most 1 arg, could be a path, or version
 	if len(args) == 0 {
-		if Active != nil {
-			Postgres = Active
-			return Active, nil
-		} else {
-			return nil, fmt.Errorf("no args & no active postgres")
+		if d.Active != nil {
+			return d.Active, nil
 		}
+		return nil, fmt.Errorf("no args & no active postgres")
 	}
 	if len(args) > 1 {
 		return nil, fmt.Errorf("too many arguments, only one path/version is allowed")
@@ -301,17 +413,17 @@ func GetPostgres(args ...string) (pi *PostgresInstall, err error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to validate pg_config path %s: %v", arg, err)
 		}
-		// if it can be found in PathMap, return it
-		if pi, ok := PathMap[realPath]; ok {
-			Postgres = pi
-			return pi, nil
-		} else {
-			Postgres, err = NewPostgresInstall(realPath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to detect PostgreSQL from %s: %v", realPath, err)
+		// if it is already detected, return it
+		for _, pi := range d.Installs {
+			if pi.PgConfigPath == realPath {
+				return pi, nil
 			}
-			return Postgres, nil
 		}
+		pi, err := NewPostgresInstall(realPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect PostgreSQL from %s: %v", realPath, err)
+		}
+		return pi, nil
 	}
 
 	// treat it as a version string
@@ -319,13 +431,75 @@ func GetPostgres(args ...string) (pi *PostgresInstall, err error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse PostgreSQL version %s: %v", arg, err)
 	}
-	if pi, ok := Installs[major]; ok {
-		Postgres = pi
+	if pi := d.Major(major); pi != nil {
 		return pi, nil
 	}
 	return nil, fmt.Errorf("PostgreSQL %d not found", major)
 }
 
+// PostgresInstallSummary print the summary of PostgreSQL installation
+// It is a compat shim around PrintPostgresSummary using package globals
+func PostgresInstallSummary() {
+	if err := DetectPostgres(); err != nil {
+		logrus.Errorf("failed to detect PostgreSQL: %v", err)
+		return
+	}
+	PrintPostgresSummary(globalDetection())
+}
+
+// PrintPostgresSummary print the summary of detected PostgreSQL installation
+func PrintPostgresSummary(d *Detection) {
+	// print installed PostgreSQL versions using tabwriter
+	writer := new(tabwriter.Writer)
+	writer.Init(os.Stdout, 0, 8, 2, ' ', 0)
+
+	if len(d.Installs) > 0 {
+		fmt.Fprintln(writer, "Installed:")
+		for _, v := range d.Installs {
+			if v == d.Active {
+				fmt.Fprintf(writer, "* %s\t%s\n", v.Version, fmt.Sprintf("%-3d Extensions", len(v.Extensions)))
+			}
+		}
+		for _, v := range d.Installs {
+			if v != d.Active {
+				fmt.Fprintf(writer, "- %s\t%s\n", v.Version, fmt.Sprintf("%-3d Extensions", len(v.Extensions)))
+			}
+		}
+	} else {
+		fmt.Fprintln(writer, "No PostgreSQL installation found")
+	}
+
+	// print active PostgreSQL detail using tabwriter
+	if active := d.Active; active != nil {
+		fmt.Fprintln(writer, "\nActive:")
+		fmt.Fprintf(writer, "PG Version\t:  %s\n", active.Version)
+		fmt.Fprintf(writer, "Config Path\t:  %s\n", active.PgConfig)
+		fmt.Fprintf(writer, "Binary Path\t:  %s\n", active.BinPath)
+		fmt.Fprintf(writer, "Library Path\t:  %s\n", active.LibPath)
+		fmt.Fprintf(writer, "Extension Path\t:  %s\n", active.ExtPath)
+	} else {
+		fmt.Fprintln(writer, "\nNo active PostgreSQL found in PATH:")
+		// split the PATH and print each path
+		paths := strings.Split(os.Getenv("PATH"), ":")
+		for _, path := range paths {
+			fmt.Fprintf(writer, "- %s\n", path)
+		}
+	}
+
+	writer.Flush()
+}
+
+// GetPostgres selects the designated PostgreSQL from package globals
+// It is a compat shim around Detection.Select, and sets Postgres on success
+func GetPostgres(args ...string) (*PostgresInstall, error) {
+	pi, err := globalDetection().Select(args...)
+	if err != nil {
+		return nil, err
+	}
+	Postgres = pi
+	return pi, nil
+}
+
 func validatePgConfigPath(path string) (string, error) {
 	if info, err := os.Stat(path); err != nil {
 		return "", fmt.Errorf("pg_config %s is not executable: %v", path, err)
@@ -0,0 +1,85 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"pig/internal/config"
+	"testing"
+)
+
+// fakePgConfig creates a fake pg_config of given version under root/bin
+func fakePgConfig(t *testing.T, root string, version string) {
+	t.Helper()
+	for _, dir := range []string{"bin", "lib", "share/extension"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	script := fmt.Sprintf("#!/bin/sh\necho 'BINDIR = %[1]s/bin'\necho 'PKGLIBDIR = %[1]s/lib'\necho 'SHAREDIR = %[1]s/share'\necho 'VERSION = PostgreSQL %[2]s'\n", root, version)
+	if err := os.WriteFile(filepath.Join(root, "bin", "pg_config"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindPostgresNoGlobals(t *testing.T) {
+	root := t.TempDir()
+	fakePgConfig(t, filepath.Join(root, "16"), "16.4")
+	fakePgConfig(t, filepath.Join(root, "17"), "17.2")
+
+	osType, searchRoot := config.OSType, PostgresExtraSearchRoot
+	installs, active, inited := Installs, Active, Inited
+	defer func() {
+		config.OSType, PostgresExtraSearchRoot = osType, searchRoot
+		Installs, Active, Inited = installs, active, inited
+	}()
+	config.OSType = config.DistroDEB
+	PostgresExtraSearchRoot = []string{filepath.Join(root, "%s")}
+	Installs, Active, Inited = nil, nil, false
+
+	d, err := NewDetection()
+	if err != nil {
+		t.Fatalf("NewDetection: %v", err)
+	}
+	all := d.Installs
+	var majors []int
+	for _, pi := range all {
+		if filepath.Dir(filepath.Dir(pi.PgConfig)) == filepath.Join(root, fmt.Sprint(pi.MajorVersion)) {
+			majors = append(majors, pi.MajorVersion)
+		}
+	}
+	if len(majors) != 2 || majors[0] != 17 || majors[1] != 16 {
+		t.Errorf("found majors %v under %s, want [17 16]", majors, root)
+	}
+	if Installs != nil || Active != nil || Inited {
+		t.Errorf("NewDetection should not touch globals")
+	}
+	pg16 := filepath.Join(root, "16", "bin", "pg_config")
+	if pi, err := d.Select(pg16); err != nil || pi.MajorVersion != 16 || pi != d.Major(16) {
+		t.Errorf("Select(%s) = %v, %v, want the detected PostgreSQL 16", pg16, pi, err)
+	}
+	if pi, err := d.Select("17"); err != nil || pi.MajorVersion != 17 {
+		t.Errorf("Select(17) = %v, %v", pi, err)
+	}
+
+	SetPostgres(nil, all)
+	if Installs[16] == nil || Installs[17] == nil || !Inited {
+		t.Errorf("SetPostgres did not set globals: %v", Installs)
+	}
+}
+
+func TestSetCatalog(t *testing.T) {
+	savedCatalog, savedActive, savedPg, savedInstalls := Catalog, Active, Postgres, Installs
+	defer func() { Catalog, Active, Postgres, Installs = savedCatalog, savedActive, savedPg, savedInstalls }()
+	old := &Extension{Name: "vector", Version: "0.7.0"}
+	loaded := &Extension{Name: "vector", Version: "0.8.0"}
+	ei := &ExtensionInstall{Extension: old, ControlName: "vector"}
+	orphan := &ExtensionInstall{ControlName: "mytoy"}
+	pi := &PostgresInstall{MajorVersion: 16, Extensions: []*ExtensionInstall{ei, orphan}}
+	Active, Postgres, Installs = pi, pi, map[int]*PostgresInstall{16: pi}
+
+	SetCatalog(&ExtensionCatalog{ExtNameMap: map[string]*Extension{"vector": loaded, "mytoy": {Name: "mytoy"}}})
+	if ei.Extension != loaded || orphan.Extension == nil || orphan.Extension.Name != "mytoy" {
+		t.Errorf("SetCatalog() should relink installed extensions to the new catalog")
+	}
+}
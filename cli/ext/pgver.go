@@ -8,9 +8,25 @@ import (
 )
 
 // ParsePostgresVersion will parse the major and minor version of PostgreSQL
+// vendor strings are tolerated, e.g. "PostgreSQL 16.2 (Ubuntu 16.2-1.pgdg22.04+1)", "EDB 16.1.0 (PostgreSQL 16.1)"
 func ParsePostgresVersion(input string) (int, int, error) {
 	s := strings.TrimSpace(input)
 
+	// 0. If there's a "PostgreSQL <digit>" token, parse from there (skip any vendor prefix with digits)
+	lower := strings.ToLower(s)
+	for offset := 0; offset < len(lower); {
+		idx := strings.Index(lower[offset:], "postgresql ")
+		if idx < 0 {
+			break
+		}
+		pos := offset + idx + len("postgresql ")
+		if pos < len(s) && unicode.IsDigit(rune(s[pos])) {
+			s = s[pos:]
+			break
+		}
+		offset = pos
+	}
+
 	// 1. Find the index of the first digit in the string
 	startIdx := -1
 	for i, r := range s {
@@ -50,7 +66,11 @@ func ParsePostgresVersion(input string) (int, int, error) {
 	minor := 0
 
 	// leftover is the substring after endIdx, e.g., ".10", ".5something", "rc2", " some-other-thing"
+	// the version token ends at the first space or '(', vendor suffix like "(Ubuntu 17~rc1-1.pgdg24.04+1)" is ignored
 	leftover := s[endIdx:]
+	if idx := strings.IndexFunc(leftover, func(r rune) bool { return unicode.IsSpace(r) || r == '(' }); idx >= 0 {
+		leftover = leftover[:idx]
+	}
 	leftover = strings.TrimLeftFunc(leftover, func(r rune) bool {
 		// Skip all characters until a '.' is found
 		return r != '.'
@@ -42,6 +42,19 @@ func TestParsePostgresVersion(t *testing.T) {
 		{"14alpha1", 14, 0, false},                     // just major
 		{"15rc2", 15, 0, false},                        // just major
 
+		// real-world pg_config --version outputs with vendor suffixes / prefixes
+		{"PostgreSQL 16.2 (Ubuntu 16.2-1.pgdg22.04+1)", 16, 2, false},
+		{"PostgreSQL 15.4 (Debian 15.4-2.pgdg120+1)", 15, 4, false},
+		{"PostgreSQL 16beta3", 16, 0, false},
+		{"PostgreSQL 17rc1 (Ubuntu 17~rc1-1.pgdg24.04+1)", 17, 0, false},
+		{"PostgreSQL 17devel", 17, 0, false},
+		{"PostgreSQL 14.11 (Homebrew)", 14, 11, false},
+		{"PostgreSQL 13.14 (Percona Distribution)", 13, 14, false},
+		{"EDB Postgres Advanced Server 16.1.0 (PostgreSQL 16.1)", 16, 1, false},
+		{"Postgres-XL 10r1.1 (PostgreSQL 10.4)", 10, 4, false},
+		{"2ndQuadrant PostgreSQL 12.18", 12, 18, false},
+		{"postgresql 16.3", 16, 3, false},
+		{"pg_config (PostgreSQL) 16.2", 16, 2, false},
 	}
 
 	for _, test := range tests {
@@ -54,3 +67,19 @@ func TestParsePostgresVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckPgMajor(t *testing.T) {
+	ec := &ExtensionCatalog{Extensions: []*Extension{
+		{Name: "a", PgVer: []string{"17", "16", "15"}},
+		{Name: "b", PgVer: []string{"14", "13"}},
+	}}
+	if got := ec.PgMajors(); len(got) != 5 || got[0] != 13 || got[4] != 17 {
+		t.Errorf("PgMajors() = %v, want [13 14 15 16 17]", got)
+	}
+	if err := ec.CheckPgMajor(16); err != nil {
+		t.Errorf("CheckPgMajor(16) = %v, want nil", err)
+	}
+	if err := ec.CheckPgMajor(166); err == nil {
+		t.Errorf("CheckPgMajor(166) should fail")
+	}
+}
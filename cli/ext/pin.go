@@ -0,0 +1,132 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"pig/internal/config"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// PinFileName is the name of the extension version pin file under config dir
+const PinFileName = "pins.yml"
+
+// PinFilePath returns the path to the extension version pin file
+func PinFilePath() string {
+	return config.ConfigPath(PinFileName)
+}
+
+// LoadPins loads extension version pins (extension name -> version) from pin file
+func LoadPins() (map[string]string, error) {
+	pins := make(map[string]string)
+	if config.ConfigDir == "" {
+		return pins, nil
+	}
+	data, err := os.ReadFile(PinFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return pins, nil
+		}
+		return nil, fmt.Errorf("failed to read pin file %s: %v", PinFilePath(), err)
+	}
+	if err := yaml.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("failed to parse pin file %s: %v", PinFilePath(), err)
+	}
+	if pins == nil {
+		pins = make(map[string]string)
+	}
+	return pins, nil
+}
+
+// SavePins writes extension version pins to pin file
+func SavePins(pins map[string]string) error {
+	if config.ConfigDir == "" {
+		return fmt.Errorf("config dir is not initialized")
+	}
+	data, err := yaml.Marshal(pins)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pins: %v", err)
+	}
+	if err := os.WriteFile(PinFilePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write pin file %s: %v", PinFilePath(), err)
+	}
+	return nil
+}
+
+// PinExtensions records version holds for given extensions in name=version format
+// if version is omitted, the installed version (or catalog version) is used
+func PinExtensions(args []string) error {
+	pins, err := LoadPins()
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		name, version, _ := strings.Cut(arg, "=")
+		ext, ok := lookupExtension(name)
+		if !ok {
+			return fmt.Errorf("extension '%s' not found", name)
+		}
+		if version == "" {
+			if Postgres != nil && Postgres.ExtensionMap[ext.Name] != nil {
+				version = Postgres.ExtensionMap[ext.Name].ActiveVersion()
+			} else {
+				version = ext.Version
+			}
+		}
+		if version == "" {
+			return fmt.Errorf("can not determine version to pin for extension %s, use %s=<version>", ext.Name, ext.Name)
+		}
+		pins[ext.Name] = version
+		logrus.Infof("pin extension %s to version %s", ext.Name, version)
+	}
+	return SavePins(pins)
+}
+
+// UnpinExtensions releases version holds for given extensions
+func UnpinExtensions(names []string) error {
+	pins, err := LoadPins()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if ext, ok := lookupExtension(name); ok && pins[name] == "" {
+			name = ext.Name
+		}
+		if _, ok := pins[name]; !ok {
+			logrus.Warnf("extension %s is not pinned", name)
+			continue
+		}
+		delete(pins, name)
+		logrus.Infof("unpin extension %s", name)
+	}
+	return SavePins(pins)
+}
+
+// ListPins prints current extension version pins
+func ListPins() error {
+	pins, err := LoadPins()
+	if err != nil {
+		return err
+	}
+	if len(pins) == 0 {
+		fmt.Println("no extension pinned")
+		return nil
+	}
+	names := make([]string, 0, len(pins))
+	for name := range pins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tVersion")
+	fmt.Fprintln(w, "----\t-------")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, pins[name])
+	}
+	w.Flush()
+	return nil
+}
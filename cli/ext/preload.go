@@ -0,0 +1,309 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ShowPreloadDiff bool   // print shared_preload_libraries before and after install, without editing it
+	EditPreload     bool   // add installed extensions that need loading into shared_preload_libraries
+	PreloadConfFile string // postgresql.conf to edit instead of ALTER SYSTEM on the running PostgreSQL
+)
+
+// ParsePreload splits a shared_preload_libraries value into libraries, quotes and blanks are removed
+func ParsePreload(value string) []string {
+	var libs []string
+	for _, lib := range strings.Split(value, ",") {
+		if lib = strings.Trim(strings.TrimSpace(lib), `"'`); lib != "" {
+			libs = append(libs, lib)
+		}
+	}
+	return libs
+}
+
+// MergePreload appends libraries not in the list yet, the order of existing ones is kept and duplicates dropped
+func MergePreload(libs []string, add ...string) []string {
+	seen := make(map[string]bool, len(libs)+len(add))
+	result := make([]string, 0, len(libs)+len(add))
+	for _, lib := range append(append([]string{}, libs...), add...) {
+		if !seen[lib] {
+			seen[lib] = true
+			result = append(result, lib)
+		}
+	}
+	return result
+}
+
+// RemovePreload removes given libraries from the list, the order of the others is kept
+func RemovePreload(libs []string, remove ...string) []string {
+	result := make([]string, 0, len(libs))
+	for _, lib := range libs {
+		if !slices.Contains(remove, lib) {
+			result = append(result, lib)
+		}
+	}
+	return result
+}
+
+// FormatPreload formats libraries as a quoted shared_preload_libraries value
+func FormatPreload(libs []string) string {
+	return "'" + strings.ReplaceAll(strings.Join(libs, ","), "'", "''") + "'"
+}
+
+// preloadConfRe matches an active shared_preload_libraries line of postgresql.conf, with optional trailing comment
+var preloadConfRe = regexp.MustCompile(`^\s*shared_preload_libraries\s*=?\s*('(?:[^']|'')*'|[^\s#]*)\s*(#.*)?$`)
+
+// confPreload returns the shared_preload_libraries value of the last active line in a config file
+func confPreload(data []byte) ([]string, bool) {
+	var value string
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if m := preloadConfRe.FindStringSubmatch(line); m != nil {
+			value, found = strings.ReplaceAll(strings.Trim(m[1], "'"), "''", "'"), true
+		}
+	}
+	return ParsePreload(value), found
+}
+
+// setConfPreload sets shared_preload_libraries in config file content, the last active line is replaced
+// and its trailing comment kept, or a new line is appended if there is none
+func setConfPreload(data []byte, libs []string) []byte {
+	lines := strings.Split(string(data), "\n")
+	last := -1
+	for i, line := range lines {
+		if preloadConfRe.MatchString(line) {
+			last = i
+		}
+	}
+	setting := "shared_preload_libraries = " + FormatPreload(libs)
+	if last < 0 {
+		content := strings.TrimSuffix(string(data), "\n")
+		if content != "" {
+			content += "\n"
+		}
+		return []byte(content + setting + "\n")
+	}
+	if comment := preloadConfRe.FindStringSubmatch(lines[last])[2]; comment != "" {
+		setting += "\t" + comment
+	}
+	lines[last] = setting
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// preloadTarget reads and writes shared_preload_libraries, with ALTER SYSTEM if file is empty, or in the config file
+type preloadTarget struct {
+	file string
+}
+
+// detectPreloadTarget uses PreloadConfFile if given, ALTER SYSTEM if PostgreSQL is running, or postgresql.conf in PGDATA
+func detectPreloadTarget() (*preloadTarget, error) {
+	if PreloadConfFile != "" {
+		return &preloadTarget{file: PreloadConfFile}, nil
+	}
+	_, err := PsqlQuery("postgres", "SHOW shared_preload_libraries;")
+	if err == nil {
+		return &preloadTarget{}, nil
+	}
+	logrus.Debugf("PostgreSQL is not reachable, fallback to edit postgresql.conf: %v", err)
+	if pgdata := os.Getenv("PGDATA"); pgdata != "" {
+		return &preloadTarget{file: filepath.Join(pgdata, "postgresql.conf")}, nil
+	}
+	return nil, fmt.Errorf("PostgreSQL is not running and PGDATA is not set, specify postgresql.conf with --conf")
+}
+
+func (t *preloadTarget) String() string {
+	if t.file == "" {
+		return "ALTER SYSTEM"
+	}
+	return t.file
+}
+
+// pendingPreloadSQL returns shared_preload_libraries from the last config file entry, which is pending until restart
+const pendingPreloadSQL = `SELECT setting FROM pg_file_settings WHERE name = 'shared_preload_libraries' AND error IS NULL ORDER BY seqno DESC LIMIT 1;`
+
+// get returns the pending shared_preload_libraries in order, so edits before a restart are not lost
+func (t *preloadTarget) get() ([]string, error) {
+	if t.file == "" {
+		rows, err := PsqlQuery("postgres", pendingPreloadSQL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query shared_preload_libraries: %v", err)
+		}
+		if len(rows) == 0 || len(rows[0]) == 0 {
+			// not set in any config file, use the running value
+			if rows, err = PsqlQuery("postgres", "SHOW shared_preload_libraries;"); err != nil {
+				return nil, fmt.Errorf("failed to query shared_preload_libraries: %v", err)
+			}
+		}
+		if len(rows) == 0 || len(rows[0]) == 0 {
+			return nil, nil
+		}
+		return ParsePreload(rows[0][0]), nil
+	}
+	file := t.effectiveFile()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", file, err)
+	}
+	libs, _ := confPreload(data)
+	return libs, nil
+}
+
+// effectiveFile returns postgresql.auto.conf beside the config file if it sets shared_preload_libraries, as it wins
+func (t *preloadTarget) effectiveFile() string {
+	auto := filepath.Join(filepath.Dir(t.file), "postgresql.auto.conf")
+	if auto == t.file {
+		return t.file
+	}
+	if data, err := os.ReadFile(auto); err == nil {
+		if _, found := confPreload(data); found {
+			logrus.Warnf("shared_preload_libraries is set in %s, which overrides %s, editing it instead", auto, t.file)
+			return auto
+		}
+	}
+	return t.file
+}
+
+// set writes shared_preload_libraries, which takes effect after PostgreSQL restart
+func (t *preloadTarget) set(libs []string) error {
+	if t.file == "" {
+		sql := fmt.Sprintf("ALTER SYSTEM SET shared_preload_libraries = %s;", FormatPreload(libs))
+		logrus.Infof("%s", sql)
+		if _, err := PsqlQuery("postgres", sql); err != nil {
+			return fmt.Errorf("failed to set shared_preload_libraries: %v", err)
+		}
+		return nil
+	}
+	file := t.effectiveFile()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", file, err)
+	}
+	logrus.Infof("set shared_preload_libraries = %s in %s", FormatPreload(libs), file)
+	return writeConfFile(file, setConfPreload(data, libs))
+}
+
+// writeConfFile replaces a config file through a temp file beside it, keeping its mode and owner
+// the owner matters when running as root, postgres could not read a root owned 0600 config file
+func writeConfFile(path string, data []byte) error {
+	mode := os.FileMode(0600)
+	uid, gid := -1, -1
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			uid, gid = int(st.Uid), int(st.Gid)
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	_ = os.Chmod(tmp.Name(), mode)
+	if uid >= 0 && (uid != os.Getuid() || gid != os.Getgid()) {
+		if err := os.Chown(tmp.Name(), uid, gid); err != nil {
+			return fmt.Errorf("failed to keep owner of %s: %v", path, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// changePreload prints the shared_preload_libraries change made by add / remove, and applies it unless dryRun
+// whether shared_preload_libraries is actually changed is returned
+func changePreload(action string, libs []string, dryRun bool) (bool, error) {
+	target, err := detectPreloadTarget()
+	if err != nil {
+		return false, err
+	}
+	before, err := target.get()
+	if err != nil {
+		return false, err
+	}
+	after := MergePreload(before, libs...)
+	if action == "remove" {
+		after = RemovePreload(before, libs...)
+	}
+	fmt.Printf("shared_preload_libraries (%s):\n- %s\n+ %s\n", target, FormatPreload(before), FormatPreload(after))
+	if dryRun {
+		return false, nil
+	}
+	if FormatPreload(after) == FormatPreload(before) {
+		logrus.Infof("shared_preload_libraries is not changed")
+		return false, nil
+	}
+	if err := target.set(after); err != nil {
+		return false, err
+	}
+	logrus.Warnf("shared_preload_libraries changed, restart PostgreSQL to take effect")
+	return true, nil
+}
+
+// EditPreloadLibraries adds or removes libraries in shared_preload_libraries of the active PostgreSQL
+func EditPreloadLibraries(action string, libs []string) error {
+	if action != "add" && action != "remove" {
+		return fmt.Errorf("invalid action %q, should be add or remove", action)
+	}
+	if len(libs) == 0 {
+		return fmt.Errorf("no library provided")
+	}
+	for _, lib := range libs {
+		if ext, ok := Catalog.ExtNameMap[lib]; ok && !ext.NeedLoad && action == "add" {
+			logrus.Warnf("extension %s does not need shared_preload_libraries", lib)
+		}
+	}
+	_, err := changePreload(action, libs, false)
+	return err
+}
+
+// PrintPreloadLibraries prints the current shared_preload_libraries of the active PostgreSQL
+func PrintPreloadLibraries() error {
+	target, err := detectPreloadTarget()
+	if err != nil {
+		return err
+	}
+	libs, err := target.get()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("shared_preload_libraries (%s) = %s\n", target, FormatPreload(libs))
+	return nil
+}
+
+// preloadLibs returns the libraries of extensions that need shared_preload_libraries
+func preloadLibs(exts []*Extension) []string {
+	var libs []string
+	for _, ext := range exts {
+		if ext.NeedLoad {
+			libs = append(libs, ext.Name)
+		}
+	}
+	return libs
+}
+
+// handlePreload prints the shared_preload_libraries change for extensions that need loading, and applies it if EditPreload
+// whether shared_preload_libraries is actually changed is returned
+func handlePreload(exts []*Extension) (bool, error) {
+	add := preloadLibs(exts)
+	if len(add) == 0 {
+		logrus.Infof("no extension needs shared_preload_libraries")
+		return false, nil
+	}
+	return changePreload("add", add, !EditPreload)
+}
@@ -0,0 +1,64 @@
+package ext
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestMergePreload(t *testing.T) {
+	tests := []struct {
+		current string
+		add     []string
+		want    string
+	}{
+		{"", []string{"timescaledb"}, "'timescaledb'"},
+		{`pg_stat_statements, "auto_explain"`, []string{"timescaledb"}, "'pg_stat_statements,auto_explain,timescaledb'"},
+		{"pg_cron,timescaledb", []string{"timescaledb", "citus", "citus"}, "'pg_cron,timescaledb,citus'"},
+	}
+	for _, tt := range tests {
+		if got := FormatPreload(MergePreload(ParsePreload(tt.current), tt.add...)); got != tt.want {
+			t.Errorf("merge %q with %v = %s, want %s", tt.current, tt.add, got, tt.want)
+		}
+	}
+	if got := ParsePreload("'a', b ,,c"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("ParsePreload() = %v", got)
+	}
+}
+
+func TestSetConfPreload(t *testing.T) {
+	conf := "#shared_preload_libraries = ''\nshared_preload_libraries = 'pg_stat_statements, auto_explain'\t# restart\nwork_mem = 4MB\n"
+	libs, found := confPreload([]byte(conf))
+	if !found || !reflect.DeepEqual(libs, []string{"pg_stat_statements", "auto_explain"}) {
+		t.Fatalf("confPreload() = %v, %v", libs, found)
+	}
+	got := string(setConfPreload([]byte(conf), RemovePreload(libs, "auto_explain")))
+	want := "#shared_preload_libraries = ''\nshared_preload_libraries = 'pg_stat_statements'\t# restart\nwork_mem = 4MB\n"
+	if got != want {
+		t.Errorf("setConfPreload() = %q, want %q", got, want)
+	}
+	if got := string(setConfPreload([]byte("work_mem = 4MB"), []string{"citus"})); got != "work_mem = 4MB\nshared_preload_libraries = 'citus'\n" {
+		t.Errorf("setConfPreload() should append a line, got %q", got)
+	}
+}
+
+func TestPreloadEffectiveFile(t *testing.T) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "postgresql.conf")
+	auto := filepath.Join(dir, "postgresql.auto.conf")
+	if err := os.WriteFile(conf, []byte("shared_preload_libraries = 'pg_cron'\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	target := &preloadTarget{file: conf}
+	if got := target.effectiveFile(); got != conf {
+		t.Errorf("effectiveFile() = %s, want %s", got, conf)
+	}
+	if err := os.WriteFile(auto, []byte("shared_preload_libraries = 'timescaledb'\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if libs, err := target.get(); err != nil || !slices.Equal(libs, []string{"timescaledb"}) {
+		t.Errorf("get() = %v, %v, want pending value from %s", libs, err, auto)
+	}
+}
@@ -0,0 +1,71 @@
+package ext
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusTextfile is the file name written into node_exporter textfile collector directory
+const PrometheusTextfile = "pig_extension.prom"
+
+// promMetric is a prometheus gauge with its help text and samples
+type promMetric struct {
+	name    string
+	help    string
+	samples []string
+}
+
+// WritePrometheus writes installed extension metrics of the designated PostgreSQL in prometheus text format
+func WritePrometheus(w io.Writer) error {
+	if Postgres == nil {
+		return fmt.Errorf("no PostgreSQL specified and not active PostgreSQL found")
+	}
+	pg := strconv.Itoa(Postgres.MajorVersion)
+	exts := append([]*ExtensionInstall{}, Postgres.Extensions...)
+	sort.Slice(exts, func(i, j int) bool { return exts[i].ExtName() < exts[j].ExtName() })
+
+	installed := &promMetric{name: "pig_extension_installed", help: "extension installed on PostgreSQL, with installed version and repo"}
+	update := &promMetric{name: "pig_extension_update_available", help: "1 if a newer extension version is available in catalog"}
+	broken := &promMetric{name: "pig_extension_broken", help: "1 if extension files are missing"}
+	for _, ei := range exts {
+		name := promLabel(ei.ExtName())
+		version, repo := ei.InstallVersion, ""
+		if ei.Extension != nil {
+			version, repo = ei.ActiveVersion(), ei.RepoName()
+		}
+		installed.samples = append(installed.samples, fmt.Sprintf(`{name="%s",pg="%s",version="%s",repo="%s"} 1`,
+			name, pg, promLabel(version), promLabel(repo)))
+		update.samples = append(update.samples, fmt.Sprintf(`{name="%s",pg="%s"} %s`, name, pg, promBool(ei.State() == StateUpdate)))
+		broken.samples = append(broken.samples, fmt.Sprintf(`{name="%s",pg="%s"} %s`, name, pg, promBool(ei.State() == StateBroken)))
+	}
+	count := &promMetric{name: "pig_extension_count", help: "number of extensions installed on PostgreSQL",
+		samples: []string{fmt.Sprintf(`{pg="%s"} %d`, pg, len(exts))}}
+
+	for _, m := range []*promMetric{installed, update, broken, count} {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name); err != nil {
+			return err
+		}
+		for _, s := range m.samples {
+			if _, err := fmt.Fprintf(w, "%s%s\n", m.name, s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// promLabel escapes a prometheus label value
+func promLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// promBool returns the prometheus sample value of a bool
+func promBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
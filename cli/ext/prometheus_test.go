@@ -0,0 +1,34 @@
+package ext
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	saved := Postgres
+	defer func() { Postgres = saved }()
+	Postgres = &PostgresInstall{MajorVersion: 16, Extensions: []*ExtensionInstall{
+		{Extension: &Extension{Name: "vector", Version: "0.8.0", Repo: "PGDG"}, InstallVersion: "0.7.0", ControlName: "vector"},
+		{ControlName: `my"toy`, InstallVersion: "1.0"},
+		{Extension: &Extension{Name: "pgrouting", Version: "3.8.0", HasSolib: true, NeedDDL: true}, ControlName: "pgrouting"},
+		{Extension: &Extension{Name: "pg_ddl", Version: "1.0", NeedDDL: true}},
+	}}
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+	for _, want := range []string{
+		`pig_extension_installed{name="my\"toy",pg="16",version="1.0",repo=""} 1`,
+		`pig_extension_update_available{name="vector",pg="16"} 1`,
+		`pig_extension_broken{name="my\"toy",pg="16"} 0`,
+		`pig_extension_broken{name="pgrouting",pg="16"} 0`, // library not matched by name is not broken
+		`pig_extension_broken{name="pg_ddl",pg="16"} 1`,    // control file missing
+		`pig_extension_count{pg="16"} 4`,
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("missing %s in:\n%s", want, buf.String())
+		}
+	}
+}
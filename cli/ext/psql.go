@@ -0,0 +1,215 @@
+package ext
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DatabaseExtensions caches enabled extensions of each database: dbname -> extname -> version
+var DatabaseExtensions map[string]map[string]string
+
+// PreloadLibraries caches shared_preload_libraries of the running PostgreSQL
+var PreloadLibraries map[string]bool
+
+// PsqlPath returns the psql binary path of the designated PostgreSQL (fallback to PATH)
+func PsqlPath() string {
+	if Postgres != nil && Postgres.BinPath != "" {
+		psql := filepath.Join(Postgres.BinPath, "psql")
+		if _, err := os.Stat(psql); err == nil {
+			return psql
+		}
+	}
+	return "psql"
+}
+
+// psqlConnectTimeout is the default PGCONNECT_TIMEOUT in seconds, so an unreachable server does not hang pig
+const psqlConnectTimeout = "5"
+
+// psqlCommand returns a psql command that never prompts for password (-w), with a connect timeout unless set
+func psqlCommand(args []string) *exec.Cmd {
+	cmd := exec.Command(PsqlPath(), append([]string{"-w"}, args...)...)
+	if os.Getenv("PGCONNECT_TIMEOUT") == "" {
+		cmd.Env = append(os.Environ(), "PGCONNECT_TIMEOUT="+psqlConnectTimeout)
+	}
+	return cmd
+}
+
+// PsqlQuery runs the query on given database with psql and returns rows of fields
+// connection parameters are taken from libpq environment variables (PGHOST, PGUSER, ...)
+func PsqlQuery(dbname, query string) ([][]string, error) {
+	args := []string{"-X", "-A", "-t", "-q", "-F", "\t", "-v", "ON_ERROR_STOP=1", "-c", query}
+	if dbname != "" {
+		args = append(args, "-d", dbname)
+	}
+	cmd := psqlCommand(args)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("psql query failed on %s: %v %s", dbname, err, strings.TrimSpace(stderr.String()))
+	}
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line == "" {
+			continue
+		}
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+	return rows, nil
+}
+
+// psqlStdin is how psql names a script read from standard input in error messages
+const psqlStdin = "<stdin>"
+
+// psqlErrorRe matches a psql script error: psql:<file>:<line>: ERROR:  <message>
+var psqlErrorRe = regexp.MustCompile(`^psql:(.+):(\d+): (?:ERROR|FATAL):\s+(.*)$`)
+
+// ScriptError is a failed statement of a psql script
+type ScriptError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+// PsqlScript runs a script on given database in one psql session without stopping on errors,
+// and returns the failed statements, err is only returned if psql could not run the script
+func PsqlScript(dbname, script string) ([]*ScriptError, error) {
+	args := []string{"-X", "-q", "-v", "ON_ERROR_STOP=0", "-v", "VERBOSITY=terse", "-f", "-"}
+	if dbname != "" {
+		args = append(args, "-d", dbname)
+	}
+	cmd := psqlCommand(args)
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("psql script failed on %s: %v %s", dbname, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseScriptErrors(stderr.String()), nil
+}
+
+// parseScriptErrors parses psql stderr into failed statements
+func parseScriptErrors(stderr string) []*ScriptError {
+	var errs []*ScriptError
+	for _, line := range strings.Split(stderr, "\n") {
+		if m := psqlErrorRe.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			errs = append(errs, &ScriptError{File: m[1], Line: n, Message: m[3]})
+		}
+	}
+	return errs
+}
+
+// ListDatabases returns all connectable non-template databases
+func ListDatabases() ([]string, error) {
+	rows, err := PsqlQuery("postgres", "SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate ORDER BY 1;")
+	if err != nil {
+		return nil, err
+	}
+	var dbs []string
+	for _, row := range rows {
+		dbs = append(dbs, row[0])
+	}
+	return dbs, nil
+}
+
+// QueryExtensions returns enabled extensions (extname -> version) of given database
+func QueryExtensions(dbname string) (map[string]string, error) {
+	rows, err := PsqlQuery(dbname, "SELECT extname, extversion FROM pg_extension ORDER BY 1;")
+	if err != nil {
+		return nil, err
+	}
+	exts := make(map[string]string, len(rows))
+	for _, row := range rows {
+		if len(row) == 2 {
+			exts[row[0]] = row[1]
+		}
+	}
+	return exts, nil
+}
+
+// DatabaseExtension is an extension created in a database, with the default version of installed control file
+type DatabaseExtension struct {
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	DefaultVersion string `json:"default_version"` // empty if control file is missing on disk
+	Schema         string `json:"schema"`
+}
+
+// QueryDatabaseExtensions returns created extensions of given database with their default versions
+func QueryDatabaseExtensions(dbname string) ([]*DatabaseExtension, error) {
+	rows, err := PsqlQuery(dbname, `SELECT e.extname, e.extversion, coalesce(a.default_version, ''), n.nspname FROM pg_extension e
+JOIN pg_namespace n ON n.oid = e.extnamespace LEFT JOIN pg_available_extensions a ON a.name = e.extname ORDER BY 1;`)
+	if err != nil {
+		return nil, err
+	}
+	var exts []*DatabaseExtension
+	for _, row := range rows {
+		if len(row) == 4 {
+			exts = append(exts, &DatabaseExtension{Name: row[0], Version: row[1], DefaultVersion: row[2], Schema: row[3]})
+		}
+	}
+	return exts, nil
+}
+
+// State returns the state of database extension: ok, update if default version differs, broken if control file is missing
+func (de *DatabaseExtension) State() string {
+	switch de.DefaultVersion {
+	case "":
+		return StateBroken
+	case de.Version:
+		return StateOK
+	}
+	return StateUpdate
+}
+
+// ScanDatabaseExtensions scans enabled extensions of all databases, the result is cached
+func ScanDatabaseExtensions() (map[string]map[string]string, error) {
+	if DatabaseExtensions != nil {
+		return DatabaseExtensions, nil
+	}
+	dbs, err := ListDatabases()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]map[string]string, len(dbs))
+	for _, db := range dbs {
+		exts, err := QueryExtensions(db)
+		if err != nil {
+			logrus.Debugf("failed to query extensions of database %s: %v", db, err)
+			continue
+		}
+		result[db] = exts
+	}
+	DatabaseExtensions = result
+	return result, nil
+}
+
+// QueryPreloadLibraries returns libraries in shared_preload_libraries of the running PostgreSQL, the result is cached
+func QueryPreloadLibraries() (map[string]bool, error) {
+	if PreloadLibraries != nil {
+		return PreloadLibraries, nil
+	}
+	rows, err := PsqlQuery("postgres", "SHOW shared_preload_libraries;")
+	if err != nil {
+		return nil, err
+	}
+	libs := make(map[string]bool)
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		for _, lib := range strings.Split(rows[0][0], ",") {
+			if lib = strings.Trim(strings.TrimSpace(lib), `"`); lib != "" {
+				libs[lib] = true
+			}
+		}
+	}
+	PreloadLibraries = libs
+	return libs, nil
+}
@@ -0,0 +1,63 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// relatedLimit is the max number of related extensions shown
+const relatedLimit = 10
+
+// RelatedExtension is an extension related to another one, and why
+type RelatedExtension struct {
+	*Extension
+	Relation string
+}
+
+// Related returns extensions sharing requires with the extension, then those in the same category, from catalog only
+func (e *Extension) Related() []*RelatedExtension {
+	var related []*RelatedExtension
+	seen := map[string]bool{e.Name: true}
+	for _, other := range Catalog.Extensions {
+		if seen[other.Name] {
+			continue
+		}
+		var shared []string
+		for _, req := range other.Requires {
+			if slices.Contains(e.Requires, req) {
+				shared = append(shared, req)
+			}
+		}
+		if len(shared) > 0 {
+			seen[other.Name] = true
+			related = append(related, &RelatedExtension{other, "requires " + strings.Join(shared, ",")})
+		}
+	}
+	for _, other := range Catalog.Extensions {
+		if !seen[other.Name] && e.Category != "" && other.Category == e.Category {
+			seen[other.Name] = true
+			related = append(related, &RelatedExtension{other, "category " + e.Category})
+		}
+	}
+	if len(related) > relatedLimit {
+		related = related[:relatedLimit]
+	}
+	return related
+}
+
+// PrintRelated prints related extensions in a short table
+func (e *Extension) PrintRelated() {
+	related := e.Related()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s related extensions:\n", e.Name)
+	fmt.Fprintln(w, "Name\tRelation\tRepo\tDescription")
+	fmt.Fprintln(w, "----\t--------\t----\t-----------")
+	for _, r := range related {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, r.Relation, r.RepoName(), r.EnDesc)
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows)\n\n", len(related))
+}
@@ -0,0 +1,90 @@
+package ext
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"pig/internal/config"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReportFile is the path to write json change report of install / remove / update, disabled if empty
+var ReportFile string
+
+// reports collects change reports of current run, one per action and pg version
+var reports []*Report
+
+// Report is a machine-readable record of an install / remove / update run
+type Report struct {
+	ID         int           `json:"id,omitempty"` // line number in history file, set when loaded
+	Action     string        `json:"action"`
+	Timestamp  time.Time     `json:"timestamp"`
+	Host       string        `json:"host"`
+	User       string        `json:"user"`
+	OSCode     string        `json:"os_code"`
+	OSArch     string        `json:"os_arch"`
+	OSVersion  string        `json:"os_version"`
+	PgVersion  int           `json:"pg_version"`
+	Requested  []string      `json:"requested"`
+	Succeeded  []*ReportItem `json:"succeeded"`
+	Failed     []*ReportItem `json:"failed"`
+	DurationMs int64         `json:"duration_ms"`
+	Error      string        `json:"error,omitempty"`
+	RolledBack []int         `json:"rolled_back,omitempty"` // history entries undone by a rollback
+}
+
+// ReportItem is an extension or package alias changed in a run
+type ReportItem struct {
+	Name     string            `json:"name"`
+	Version  string            `json:"version,omitempty"`
+	Packages []string          `json:"packages"`
+	Prior    map[string]string `json:"prior,omitempty"` // installed package versions before the change
+	Added    []string          `json:"added,omitempty"` // packages newly installed by the change
+}
+
+// newReport starts a change report for given action
+func newReport(action string, pgVer int, names []string) *Report {
+	host, _ := os.Hostname()
+	return &Report{
+		Action:    action,
+		Timestamp: time.Now(),
+		Host:      host,
+		User:      config.CurrentUser,
+		OSCode:    config.OSCode,
+		OSArch:    config.OSArch,
+		OSVersion: config.OSVersionFull,
+		PgVersion: pgVer,
+		Requested: names,
+		Succeeded: []*ReportItem{},
+		Failed:    []*ReportItem{},
+	}
+}
+
+// finish records the result of the run and appends it to history, the report is written later by WriteReport
+func (r *Report) finish(err error) {
+	r.DurationMs = time.Since(r.Timestamp).Milliseconds()
+	if err != nil {
+		r.Error = err.Error()
+	}
+	reports = append(reports, r)
+	if err := appendHistory(r); err != nil {
+		logrus.Warn(err)
+	}
+}
+
+// WriteReport writes collected change reports to ReportFile as a json array
+func WriteReport() error {
+	if ReportFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %v", err)
+	}
+	if err := os.WriteFile(ReportFile, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write report %s: %v", ReportFile, err)
+	}
+	return nil
+}
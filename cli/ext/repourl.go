@@ -0,0 +1,105 @@
+package ext
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RepoURL overrides the repo used for a single install, bypassing the catalog repo
+// on deb it could be "URL" (flat repo) or "URL suite component..."
+var RepoURL string
+
+const repoURLName = "pig-repo-url"
+
+// repoURLArgs are extra install args of the temporary repo set up for RepoURL
+var repoURLArgs []string
+
+// CheckRepoURL validates the repo url given by --repo-url
+func CheckRepoURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	fields := strings.Fields(raw)
+	if len(fields) > 1 && config.OSType != config.DistroDEB {
+		return fmt.Errorf("invalid repo url %q, suite & components are only supported on deb", raw)
+	}
+	u, err := url.Parse(fields[0])
+	if err != nil {
+		return fmt.Errorf("invalid repo url %q: %v", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return fmt.Errorf("invalid repo url %q: missing host", raw)
+		}
+	case "file":
+		if u.Path == "" {
+			return fmt.Errorf("invalid repo url %q: missing path", raw)
+		}
+	default:
+		return fmt.Errorf("invalid repo url %q: scheme should be http, https or file", raw)
+	}
+	return nil
+}
+
+// debRepoLine returns the apt source line of the repo url
+func debRepoLine(raw string) string {
+	fields := strings.Fields(raw)
+	if len(fields) == 1 {
+		fields = append(fields, "./")
+	}
+	return fmt.Sprintf("deb [trusted=yes] %s\n", strings.Join(fields, " "))
+}
+
+// setupRepoURL prepares the temporary repo of RepoURL, returns extra install args and a cleanup function
+func setupRepoURL() ([]string, func(), error) {
+	noop := func() {}
+	if RepoURL == "" {
+		return nil, noop, nil
+	}
+	logrus.Warnf("--repo-url %s bypasses the catalog's trusted repo, packages are installed without signature check", RepoURL)
+	switch config.OSType {
+	case config.DistroEL:
+		return []string{
+			"--repofrompath=" + repoURLName + "," + RepoURL,
+			"--enablerepo=" + repoURLName,
+			"--setopt=" + repoURLName + ".gpgcheck=0",
+		}, noop, nil
+	case config.DistroDEB:
+		listFile := repoURLName + ".list"
+		listPath := filepath.Join("/etc/apt/sources.list.d", listFile)
+		tmpFile, err := os.CreateTemp("", repoURLName+"-*.list")
+		if err != nil {
+			return nil, noop, err
+		}
+		defer os.Remove(tmpFile.Name())
+		if _, err := tmpFile.WriteString(debRepoLine(RepoURL)); err != nil {
+			tmpFile.Close()
+			return nil, noop, err
+		}
+		tmpFile.Close()
+		if err := utils.SudoCommand([]string{"install", "-m", "0644", tmpFile.Name(), listPath}); err != nil {
+			return nil, noop, fmt.Errorf("failed to add temporary repo %s: %v", listPath, err)
+		}
+		cleanup := func() {
+			if err := utils.SudoCommand([]string{"rm", "-f", listPath}); err != nil {
+				logrus.Warnf("failed to remove temporary repo %s: %v", listPath, err)
+			}
+		}
+		// refresh the temporary repo only, keep other repo indexes as is
+		update := []string{"apt-get", "update", "-o", "Dir::Etc::sourcelist=sources.list.d/" + listFile, "-o", "Dir::Etc::sourceparts=-", "-o", "APT::Get::List-Cleanup=0"}
+		if err := utils.SudoCommand(update); err != nil {
+			cleanup()
+			return nil, noop, fmt.Errorf("failed to update temporary repo %s: %v", RepoURL, err)
+		}
+		return nil, cleanup, nil
+	}
+	return nil, noop, fmt.Errorf("--repo-url is not supported on %s", config.OSType)
+}
@@ -0,0 +1,98 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Resolution is the resolved result of an install target: canonical extension name and packages
+type Resolution struct {
+	Input     string   `json:"input"`
+	Name      string   `json:"name,omitempty"` // canonical extension name, empty for non-extension targets
+	Version   string   `json:"version,omitempty"`
+	Repo      string   `json:"repo,omitempty"`
+	Packages  []string `json:"packages"`
+	PgVersion int      `json:"pg_version"`
+	OSType    string   `json:"os_type"`
+	OSCode    string   `json:"os_code"`
+}
+
+// lookupExtension finds an extension by name, then by alias, then by provided virtual name
+func lookupExtension(name string) (*Extension, bool) {
+	if ext, ok := Catalog.ExtNameMap[name]; ok {
+		return ext, true
+	}
+	if ext, ok := Catalog.ExtAliasMap[name]; ok {
+		return ext, true
+	}
+	if providers := Catalog.ProvideMap[name]; len(providers) > 0 {
+		if len(providers) > 1 {
+			var names []string
+			for _, p := range providers {
+				names = append(names, p.Name)
+			}
+			logrus.Infof("%s is provided by %s, choose %s", name, strings.Join(names, ", "), providers[0].Name)
+		}
+		return providers[0], true
+	}
+	return nil, false
+}
+
+// ResolveExtension resolves an extension name or alias to canonical name and package names for given pg version
+func ResolveExtension(name string, pgVer int) (*Resolution, error) {
+	if pgVer == 0 {
+		pgVer = PostgresLatestMajorVersion
+	}
+	Catalog.LoadAliasMap(config.OSType)
+	r := &Resolution{Input: name, PgVersion: pgVer, OSType: config.OSType, OSCode: config.OSCode}
+	ext, ok := lookupExtension(name)
+	if !ok {
+		if pgPkg, ok := Catalog.AliasMap[name]; ok {
+			r.Packages = processPkgName(pgPkg, pgVer)
+			return r, nil
+		}
+		return nil, fmt.Errorf("extension '%s' not found", name)
+	}
+	r.Name = ext.Name
+	r.Repo = ext.RepoName()
+	r.Version = ext.PgPackageVersion(pgVer)
+	r.Packages = processPkgName(ext.PackageName(pgVer), pgVer)
+	return r, nil
+}
+
+// PrintResolutions resolves given names and prints the result in table or json format
+func PrintResolutions(names []string, pgVer int, format string) error {
+	var results []*Resolution
+	var failed []string
+	for _, name := range names {
+		r, err := ResolveExtension(name, pgVer)
+		if err != nil {
+			failed = append(failed, name)
+			continue
+		}
+		results = append(results, r)
+	}
+	if format == "json" {
+		if err := utils.PrintJSON(results); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Input\tName\tVersion\tRepo\tPG\tPackages")
+		fmt.Fprintln(w, "-----\t----\t-------\t----\t--\t--------")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", r.Input, r.Name, r.Version, r.Repo, r.PgVersion, strings.Join(r.Packages, " "))
+		}
+		w.Flush()
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to resolve: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
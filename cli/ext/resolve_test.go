@@ -0,0 +1,84 @@
+package ext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupProvides(t *testing.T) {
+	header := "id,name,alias,category,url,license,tags,version,repo,lang,utility,lead,has_solib,need_ddl,need_load,trusted,relocatable,schemas,pg_ver,requires,rpm_ver,rpm_repo,rpm_pkg,rpm_pg,rpm_deps,deb_ver,deb_repo,deb_pkg,deb_deps,deb_pg,bad_case,en_desc,zh_desc,comment,config,source,provides"
+	row := func(id, name, provides string) string {
+		fields := make([]string, 37)
+		fields[0], fields[1], fields[2], fields[36] = id, name, name, provides
+		return strings.Join(fields, ",")
+	}
+	data := strings.Join([]string{header, row("2", "pgvector_alt", "vecdb"), row("1", "vecstore", `"vecdb,vector_store"`), row("3", "plain", "")}, "\n")
+
+	saved := Catalog
+	defer func() { Catalog = saved }()
+	Catalog = &ExtensionCatalog{}
+	if err := Catalog.Load([]byte(data)); err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"plain", "plain"},
+		{"vector_store", "vecstore"},
+		{"vecdb", "vecstore"}, // multiple providers, lowest id wins
+	}
+	for _, tt := range tests {
+		ext, ok := lookupExtension(tt.name)
+		if !ok || ext.Name != tt.want {
+			t.Errorf("lookupExtension(%s) = %v, want %s", tt.name, ext, tt.want)
+		}
+	}
+	if _, ok := lookupExtension("nothing"); ok {
+		t.Errorf("lookupExtension(nothing) should fail")
+	}
+	if got := InfoCandidates("vector_store"); len(got) != 1 || got[0].Name != "vecstore" {
+		t.Errorf("InfoCandidates(vector_store) = %v, want vecstore", got)
+	}
+}
+
+func TestCheckRepoURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"", false},
+		{"https://repo.example.com/pgsql", false},
+		{"file:///data/repo", false},
+		{"ftp://repo.example.com", true},
+		{"https:///nohost", true},
+		{"repo.example.com/pgsql", true},
+	}
+	for _, tt := range tests {
+		if err := CheckRepoURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("CheckRepoURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExtensionExists(t *testing.T) {
+	savedCatalog, savedPg := Catalog, Postgres
+	defer func() { Catalog, Postgres = savedCatalog, savedPg }()
+	vector := &Extension{Name: "vector", Alias: "pgvector"}
+	Catalog = &ExtensionCatalog{ExtNameMap: map[string]*Extension{"vector": vector}, ExtAliasMap: map[string]*Extension{"pgvector": vector}}
+	Postgres = nil
+
+	if name, ok := ExtensionExists("pgvector", false); !ok || name != "vector" {
+		t.Errorf("ExtensionExists(pgvector) = %s, %v, want vector, true", name, ok)
+	}
+	if _, ok := ExtensionExists("nothing", false); ok {
+		t.Errorf("ExtensionExists(nothing) should be false")
+	}
+	if _, ok := ExtensionExists("vector", true); ok {
+		t.Errorf("ExtensionExists(vector, installed) should be false without PostgreSQL")
+	}
+	Postgres = &PostgresInstall{ExtensionMap: map[string]*ExtensionInstall{"vector": {Extension: vector}}}
+	if _, ok := ExtensionExists("vector", true); !ok {
+		t.Errorf("ExtensionExists(vector, installed) should be true")
+	}
+}
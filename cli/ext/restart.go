@@ -0,0 +1,154 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// restartNeeded returns extensions that need shared_preload_libraries and a restart
+func restartNeeded(exts []*Extension) []*Extension {
+	preload, err := QueryPreloadLibraries()
+	if err != nil {
+		logrus.Debugf("failed to query shared_preload_libraries: %v", err)
+	}
+	var result []*Extension
+	for _, ext := range exts {
+		if ext.NeedLoad && !preload[ext.Name] {
+			result = append(result, ext)
+		}
+	}
+	return result
+}
+
+// printRestartNotice prints a notice for installed extensions that need shared_preload_libraries and a restart
+func printRestartNotice(exts []*Extension, preloadChanged bool) {
+	for _, ext := range exts {
+		fmt.Printf("⚠ %s requires adding to shared_preload_libraries and a server restart\n", ext.Name)
+	}
+	if len(exts) > 0 && !preloadChanged {
+		fmt.Println("hint: use --edit-preload to add them to shared_preload_libraries, or 'pig ext edit-preload add <lib>'")
+	}
+}
+
+// PendingRestartExtensions returns installed extensions that need preload but are not in shared_preload_libraries
+func PendingRestartExtensions() ([]*ExtensionInstall, error) {
+	if Postgres == nil {
+		return nil, fmt.Errorf("no PostgreSQL specified and not active PostgreSQL found")
+	}
+	preload, err := QueryPreloadLibraries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shared_preload_libraries: %v", err)
+	}
+	var pending []*ExtensionInstall
+	for _, ei := range Postgres.Extensions {
+		if ei.Extension != nil && ei.NeedLoad && !preload[ei.Name] {
+			pending = append(pending, ei)
+		}
+	}
+	return pending, nil
+}
+
+// PrintPendingRestart prints installed but not yet loaded extensions
+func PrintPendingRestart() error {
+	pending, err := PendingRestartExtensions()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Println("no extension pending restart")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tVersion\tPackage")
+	fmt.Fprintln(w, "----\t-------\t-------")
+	for _, ei := range pending {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", ei.Name, ei.ActiveVersion(), ei.PackageName(Postgres.MajorVersion))
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows) add them to shared_preload_libraries and restart PostgreSQL to take effect\n\n", len(pending))
+	return nil
+}
+
+// RestartService restarts the PostgreSQL service after install if a restart is recommended, with confirmation
+var RestartService bool
+
+// restartReasons returns why a service restart is recommended after installing given packages and extensions
+// extensions pending preload only count if shared_preload_libraries was changed, a restart alone won't load them
+func restartReasons(pkgs []string, pending []*Extension, preloadChanged bool) []string {
+	var reasons []string
+	for _, pkg := range pkgs {
+		if serverPackageRe.MatchString(pkg) {
+			reasons = append(reasons, fmt.Sprintf("PostgreSQL kernel package %s installed", pkg))
+		}
+	}
+	if !preloadChanged {
+		return reasons
+	}
+	for _, ext := range pending {
+		reasons = append(reasons, fmt.Sprintf("%s added to shared_preload_libraries", ext.Name))
+	}
+	return reasons
+}
+
+// serviceCandidates returns possible PostgreSQL systemd units for given major version, in preference order
+func serviceCandidates(pgVer int) []string {
+	candidates := []string{"patroni", "postgres"}
+	switch config.OSType {
+	case config.DistroEL:
+		candidates = append(candidates, fmt.Sprintf("postgresql-%d", pgVer))
+	case config.DistroDEB:
+		candidates = append(candidates, fmt.Sprintf("postgresql@%d-main", pgVer))
+	}
+	return append(candidates, "postgresql")
+}
+
+// restartCommand returns the command to restart PostgreSQL with the detected init system
+// the first active candidate unit is used with systemd, otherwise the os default unit, or pg_ctl without systemd
+func restartCommand(pgVer int) []string {
+	if _, err := os.Stat("/run/systemd/system"); err != nil {
+		return []string{"pg_ctl", "-D", "<PGDATA>", "restart"}
+	}
+	candidates := serviceCandidates(pgVer)
+	for _, unit := range candidates {
+		if exec.Command("systemctl", "is-active", "--quiet", unit).Run() == nil {
+			return []string{"systemctl", "restart", unit}
+		}
+	}
+	return []string{"systemctl", "restart", candidates[len(candidates)-2]}
+}
+
+// recommendRestart prints why a restart is recommended and how, and performs it if RestartService is set
+func recommendRestart(reasons []string, pgVer int, yes bool) error {
+	if len(reasons) == 0 {
+		return nil
+	}
+	cmd := restartCommand(pgVer)
+	cmdline := strings.Join(cmd, " ")
+	if cmd[0] == "systemctl" {
+		cmdline = "sudo " + cmdline
+	}
+	fmt.Printf("service restart recommended: %s\n", strings.Join(reasons, ", "))
+	if !RestartService {
+		fmt.Printf("hint: run '%s' or use --restart\n", cmdline)
+		return nil
+	}
+	if cmd[0] != "systemctl" {
+		return fmt.Errorf("no systemd found, restart PostgreSQL manually as dbsu: %s", cmdline)
+	}
+	if !yes && !utils.Confirm(fmt.Sprintf("restart PostgreSQL with '%s'?", cmdline)) {
+		logrus.Warnf("restart skipped, run '%s' to take effect", cmdline)
+		return nil
+	}
+	logrus.Infof("restarting PostgreSQL: %s", strings.Join(cmd, " "))
+	if err := utils.SudoCommand(cmd); err != nil {
+		return fmt.Errorf("failed to restart PostgreSQL: %v", err)
+	}
+	return nil
+}
@@ -2,15 +2,26 @@ package ext
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"pig/internal/config"
 	"pig/internal/utils"
+	"regexp"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	Purge             bool // also remove package config files and extension leftovers under pg sharedir
+	AllowRemoveServer bool // allow removal that takes PostgreSQL server packages away
+)
+
+// serverPackageRe matches PostgreSQL server & core packages that extension removal should never take away
+var serverPackageRe = regexp.MustCompile(`^(postgresql-\d+|postgresql-common|postgresql\d+|postgresql\d+-server|postgresql\d+-libs)$`)
+
 // RemoveExtensions will remove extension based on provided names, aliases, or categories
-func RemoveExtensions(pgVer int, names []string, yes bool) error {
+func RemoveExtensions(pgVer int, names []string, yes bool) (err error) {
 	logrus.Debugf("removing extensions: pgVer=%d, names=%s, yes=%v", pgVer, strings.Join(names, ", "), yes)
 	if len(names) == 0 {
 		return fmt.Errorf("no extension names provided")
@@ -19,38 +30,26 @@ func RemoveExtensions(pgVer int, names []string, yes bool) error {
 		logrus.Debugf("no PostgreSQL version specified, set target version to the latest major version: %d", PostgresLatestMajorVersion)
 		pgVer = PostgresLatestMajorVersion
 	}
+	report := newReport("remove", pgVer, names)
+	defer func() { report.finish(err) }()
 
-	var removeCmds []string
-	Catalog.LoadAliasMap(config.OSType)
-	switch config.OSType {
-	case config.DistroEL:
-		removeCmds = append(removeCmds, []string{"yum", "remove"}...)
-		if config.OSVersion == "8" || config.OSVersion == "9" {
-			removeCmds[0] = "dnf"
-		}
-		if yes {
-			removeCmds = append(removeCmds, "-y")
-		}
-	case config.DistroDEB:
-		removeCmds = append(removeCmds, []string{"apt-get", "remove"}...)
-		if yes {
-			removeCmds = append(removeCmds, "-y")
-		}
-	default:
-		return fmt.Errorf("unsupported OS type: %s", config.OSType)
+	backend, err := GetBackend()
+	if err != nil {
+		return err
 	}
+	Catalog.LoadAliasMap(config.OSType)
 
 	var pkgNames []string
+	var items []*ReportItem
+	var purgeExts []*Extension
 	for _, name := range names {
-		ext, ok := Catalog.ExtNameMap[name]
-		if !ok {
-			ext, ok = Catalog.ExtAliasMap[name]
-		}
+		ext, ok := lookupExtension(name)
 
 		if !ok {
 			// try to find in PostgresPackageMap (if it is not a postgres extension)
 			if pgPkg, ok := Catalog.AliasMap[name]; ok {
-				pkgNames = append(pkgNames, processPkgName(pgPkg, pgVer)...)
+				pkgNames = append(pkgNames, backend.Resolve(pgPkg, "", pgVer)...)
+				items = append(items, &ReportItem{Name: name, Packages: backend.Resolve(pgPkg, "", pgVer)})
 				continue
 			} else {
 				logrus.Debugf("can not found '%s' in extension name or alias", name)
@@ -62,15 +61,174 @@ func RemoveExtensions(pgVer int, names []string, yes bool) error {
 			logrus.Warnf("no package found for extension %s", ext.Name)
 			continue
 		}
+		purgeExts = append(purgeExts, ext)
 		logrus.Debugf("translate extension %s to package name: %s", ext.Name, pkgName)
-		pkgNames = append(pkgNames, processPkgName(pkgName, pgVer)...)
+		pkgNames = append(pkgNames, backend.Resolve(pkgName, "", pgVer)...)
+		items = append(items, &ReportItem{Name: ext.Name, Version: ext.PgPackageVersion(pgVer), Packages: backend.Resolve(pkgName, "", pgVer)})
 	}
 
 	if len(pkgNames) == 0 {
 		return fmt.Errorf("no packages to be removed")
 	}
-	removeCmds = append(removeCmds, pkgNames...)
-	logrus.Infof("removing extensions: %s", strings.Join(removeCmds, " "))
+	if err := checkServerRemoval(backend, pkgNames); err != nil {
+		return err
+	}
+	logrus.Infof("removing extensions: %s", strings.Join(pkgNames, " "))
+
+	var configs []string
+	if !Purge {
+		configs = packageConfigFiles(backend, pkgNames)
+	}
+	recordPrior(backend, items)
+	if err := backend.Remove(pkgNames, yes); err != nil {
+		report.Failed = items
+		return err
+	}
+	report.Succeeded = items
+	if Purge {
+		logrus.Infof("removed in purge mode, package config files are removed too")
+		return purgeLeftovers(backend, purgeExts, yes)
+	}
+	printKeptFiles(backend, configs, purgeExts)
+	return nil
+}
+
+// configLister is implemented by backends that could list config files owned by installed packages
+type configLister interface {
+	ConfigFiles(pkgs []string) ([]string, error)
+}
+
+// packageConfigFiles returns config files owned by given packages, queried before they are removed
+func packageConfigFiles(backend PackageBackend, pkgs []string) []string {
+	lister, ok := backend.(configLister)
+	if !ok {
+		return nil
+	}
+	files, err := lister.ConfigFiles(pkgs)
+	if err != nil {
+		logrus.Debugf("failed to list package config files: %v", err)
+	}
+	return files
+}
 
-	return utils.SudoCommand(removeCmds)
+// keptConfigFiles returns config files still on disk after removal, rpm saves modified ones as .rpmsave
+func keptConfigFiles(configs []string) []string {
+	var kept []string
+	for _, file := range configs {
+		for _, path := range []string{file, file + ".rpmsave"} {
+			if _, err := os.Stat(path); err == nil {
+				kept = append(kept, path)
+			}
+		}
+	}
+	return kept
+}
+
+// printKeptFiles reports config files and extension leftovers kept by a non-purge removal
+func printKeptFiles(backend PackageBackend, configs []string, exts []*Extension) {
+	kept := keptConfigFiles(configs)
+	if Postgres != nil && Postgres.ExtPath != "" {
+		kept = append(kept, leftoverPaths(backend, Postgres, exts)...)
+	}
+	if len(kept) == 0 {
+		logrus.Infof("removed in keep-config mode, no config files or leftovers kept")
+		return
+	}
+	logrus.Infof("removed in keep-config mode, %d config files and leftovers kept:", len(kept))
+	for _, path := range kept {
+		fmt.Fprintf(os.Stderr, "  - %s\n", path)
+	}
+	fmt.Fprintln(os.Stderr, "hint: use --purge to remove them as well")
+}
+
+// checkServerRemoval simulates the removal, and refuses it if PostgreSQL server packages would be removed too
+func checkServerRemoval(backend PackageBackend, pkgs []string) error {
+	sim, ok := backend.(removeSimulator)
+	if !ok {
+		return nil
+	}
+	removed, err := sim.SimulateRemove(pkgs)
+	if err != nil {
+		if AllowRemoveServer {
+			logrus.Warnf("can not check whether PostgreSQL server would be removed: %v", err)
+			return nil
+		}
+		return fmt.Errorf("can not check whether PostgreSQL server would be removed: %v, use --allow-remove-server to skip the check", err)
+	}
+	var servers []string
+	for _, pkg := range removed {
+		if serverPackageRe.MatchString(pkg) {
+			servers = append(servers, pkg)
+		}
+	}
+	if len(servers) == 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "following %d packages would be removed:\n", len(removed))
+	for _, pkg := range removed {
+		fmt.Fprintf(os.Stderr, "  - %s\n", pkg)
+	}
+	if AllowRemoveServer {
+		logrus.Warnf("--allow-remove-server is set, removing PostgreSQL server packages: %s", strings.Join(servers, ", "))
+		return nil
+	}
+	return fmt.Errorf("removal would take PostgreSQL server packages away: %s, use --allow-remove-server if it is intended", strings.Join(servers, ", "))
+}
+
+// fileOwnerChecker is implemented by backends that could tell whether a path is owned by an installed package
+type fileOwnerChecker interface {
+	FileOwned(path string) bool
+}
+
+// leftoverPaths returns files and directories of given extensions still left under pg sharedir
+// paths still owned by an installed package (e.g. another extension shipping the same files) are skipped
+func leftoverPaths(backend PackageBackend, pg *PostgresInstall, exts []*Extension) []string {
+	checker, ok := backend.(fileOwnerChecker)
+	if !ok {
+		logrus.Debugf("package backend can not check file owners, no leftovers reported")
+		return nil
+	}
+	shareDir := filepath.Dir(pg.ExtPath)
+	var paths []string
+	for _, ext := range exts {
+		patterns := []string{
+			filepath.Join(pg.ExtPath, ext.Name+".control"),
+			filepath.Join(pg.ExtPath, ext.Name+"--*.sql"),
+			filepath.Join(shareDir, ext.Name),
+		}
+		for _, pattern := range patterns {
+			matches, _ := filepath.Glob(pattern)
+			for _, path := range matches {
+				if checker.FileOwned(path) {
+					logrus.Debugf("skip %s, still owned by an installed package", path)
+					continue
+				}
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// purgeLeftovers removes extension files left under pg sharedir after package removal, ask before deleting unless yes
+func purgeLeftovers(backend PackageBackend, exts []*Extension, yes bool) error {
+	if Postgres == nil || Postgres.ExtPath == "" {
+		logrus.Debugf("no PostgreSQL found, skip purging extension leftovers")
+		return nil
+	}
+	paths := leftoverPaths(backend, Postgres, exts)
+	if len(paths) == 0 {
+		logrus.Infof("no extension leftovers found in %s", filepath.Dir(Postgres.ExtPath))
+		return nil
+	}
+	fmt.Fprintln(os.Stderr, "following files are not owned by any package anymore:")
+	for _, path := range paths {
+		fmt.Fprintf(os.Stderr, "  - %s\n", path)
+	}
+	if !yes && !utils.Confirm("delete them?") {
+		logrus.Infof("skip purging %d extension leftovers", len(paths))
+		return nil
+	}
+	logrus.Infof("purging %d extension leftovers", len(paths))
+	return utils.SudoCommand(append([]string{"rm", "-rf"}, paths...))
 }
@@ -0,0 +1,234 @@
+package ext
+
+import (
+	"fmt"
+	"path"
+	"pig/internal/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RollbackTo is the history entry id to roll back to (inclusive), only the latest operation is undone if 0
+var RollbackTo int
+
+// versionInstaller is implemented by backends that could install exact package versions, downgrading if needed
+type versionInstaller interface {
+	InstallVersions(versions map[string]string, downgrade bool, yes bool) error
+}
+
+// recordPrior keeps installed versions of each item's packages in the report, so the change could be rolled back
+func recordPrior(backend PackageBackend, items []*ReportItem) {
+	var pkgs []string
+	for _, item := range items {
+		pkgs = append(pkgs, item.Packages...)
+	}
+	installed, err := queryInstalled(backend, pkgs)
+	if err != nil {
+		logrus.Debugf("failed to query installed versions: %v", err)
+		return
+	}
+	for _, item := range items {
+		for name, version := range installed {
+			if matchAnyPackage(item.Packages, name) {
+				if item.Prior == nil {
+					item.Prior = make(map[string]string)
+				}
+				item.Prior[name] = version
+			}
+		}
+	}
+}
+
+// recordAdded keeps packages of the item installed now but not in prior, so an install rollback removes only them
+func recordAdded(backend PackageBackend, item *ReportItem, prior map[string]string) {
+	installed, err := queryInstalled(backend, item.Packages)
+	if err != nil {
+		logrus.Debugf("failed to query installed packages: %v", err)
+		return
+	}
+	for name := range installed {
+		if _, ok := prior[name]; !ok && matchAnyPackage(item.Packages, name) {
+			item.Added = append(item.Added, name)
+		}
+	}
+	sort.Strings(item.Added)
+}
+
+// queryInstalled queries installed versions of package specs, version pins like pkg=1.2* are stripped first
+func queryInstalled(backend PackageBackend, pkgs []string) (map[string]string, error) {
+	names := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		name, _, _ := strings.Cut(pkg, "=")
+		names = append(names, name)
+	}
+	return backend.Query(names)
+}
+
+// matchAnyPackage reports whether an installed package name matches one of the package specs
+// specs may be globs (pkg_16*), or pinned to a version (apt pkg=1.2*, dnf pkg-1.2)
+func matchAnyPackage(specs []string, name string) bool {
+	for _, spec := range specs {
+		if ok, _ := path.Match(spec, name); ok || spec == name ||
+			strings.HasPrefix(spec, name+"=") || strings.HasPrefix(spec, name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// rollbackTargets returns history entries to be undone, the latest first
+// rollback entries and entries already rolled back are skipped
+func rollbackTargets(entries []*Report, to int) ([]*Report, error) {
+	done := make(map[int]bool)
+	for _, r := range entries {
+		for _, id := range r.RolledBack {
+			done[id] = true
+		}
+	}
+	var targets []*Report
+	for i := len(entries) - 1; i >= 0; i-- {
+		r := entries[i]
+		if to > 0 && r.ID < to {
+			break
+		}
+		if r.Action == "rollback" || len(r.Succeeded) == 0 || done[r.ID] {
+			if r.ID == to {
+				return nil, fmt.Errorf("history entry #%d is a rollback, failed or already rolled back", to)
+			}
+			continue
+		}
+		targets = append(targets, r)
+		if to == 0 || r.ID == to {
+			return targets, nil
+		}
+	}
+	if to > 0 {
+		return nil, fmt.Errorf("history entry #%d not found", to)
+	}
+	return nil, fmt.Errorf("no operation to roll back in %s", HistoryFilePath())
+}
+
+// undoPlan describes how the history entry will be undone
+func undoPlan(r *Report) string {
+	var items []string
+	for _, item := range r.Succeeded {
+		switch {
+		case r.Action == "install":
+			if len(item.Added) > 0 {
+				items = append(items, strings.Join(item.Added, " "))
+			}
+		case len(item.Prior) > 0:
+			var versions []string
+			for pkg, ver := range item.Prior {
+				versions = append(versions, pkg+"="+ver)
+			}
+			sort.Strings(versions)
+			items = append(items, strings.Join(versions, " "))
+		default:
+			items = append(items, item.Name)
+		}
+	}
+	if len(items) == 0 {
+		items = append(items, "nothing")
+	}
+	verb := map[string]string{"install": "remove", "remove": "reinstall", "update": "downgrade"}[r.Action]
+	return fmt.Sprintf("#%d %s %s -> %s %s", r.ID, r.Timestamp.Local().Format("2006-01-02 15:04:05"), r.Action, verb, strings.Join(items, ", "))
+}
+
+// undo reverts a history entry: removes what was installed, reinstalls what was removed, downgrades what was updated
+func undo(backend PackageBackend, r *Report, yes bool) error {
+	var pkgs []string
+	versions := make(map[string]string)
+	for _, item := range r.Succeeded {
+		if len(item.Prior) == 0 {
+			pkgs = append(pkgs, item.Packages...)
+		}
+		for pkg, ver := range item.Prior {
+			versions[pkg] = ver
+		}
+	}
+	switch r.Action {
+	case "install":
+		// packages installed before are kept, only the ones added by this install are removed
+		var added []string
+		for _, item := range r.Succeeded {
+			added = append(added, item.Added...)
+		}
+		added = dedupPkgNames(added)
+		if len(added) == 0 {
+			logrus.Infof("install #%d added no new packages, nothing to remove", r.ID)
+			return nil
+		}
+		if err := checkServerRemoval(backend, added); err != nil {
+			return err
+		}
+		return backend.Remove(added, yes)
+	case "remove":
+		if len(versions) > 0 {
+			vi, ok := backend.(versionInstaller)
+			if !ok {
+				return fmt.Errorf("package backend does not support installing exact versions")
+			}
+			if err := vi.InstallVersions(versions, false, yes); err != nil {
+				return err
+			}
+		}
+		if len(pkgs) > 0 {
+			return backend.Install(pkgs, yes)
+		}
+		return nil
+	case "update":
+		if len(versions) == 0 {
+			return fmt.Errorf("no prior versions recorded for update #%d", r.ID)
+		}
+		vi, ok := backend.(versionInstaller)
+		if !ok {
+			return fmt.Errorf("package backend does not support downgrade")
+		}
+		return vi.InstallVersions(versions, true, yes)
+	}
+	return fmt.Errorf("can not roll back %s #%d", r.Action, r.ID)
+}
+
+// Rollback undoes the latest install / remove / update recorded in history, or all of them since RollbackTo
+func Rollback(yes bool) (err error) {
+	entries, err := LoadHistory(time.Time{})
+	if err != nil {
+		return err
+	}
+	targets, err := rollbackTargets(entries, RollbackTo)
+	if err != nil {
+		return err
+	}
+	fmt.Println("rollback plan:")
+	for _, r := range targets {
+		fmt.Printf("  %s\n", undoPlan(r))
+	}
+	if !yes && !utils.Confirm(fmt.Sprintf("roll back %d operations?", len(targets))) {
+		return fmt.Errorf("rollback cancelled")
+	}
+	backend, err := GetBackend()
+	if err != nil {
+		return err
+	}
+
+	var requested []string
+	for _, r := range targets {
+		requested = append(requested, fmt.Sprintf("#%d", r.ID))
+	}
+	report := newReport("rollback", targets[0].PgVersion, requested)
+	defer func() { report.finish(err) }()
+	for _, r := range targets {
+		if err := undo(backend, r, yes); err != nil {
+			report.Failed = append(report.Failed, r.Succeeded...)
+			return fmt.Errorf("failed to roll back #%d: %w", r.ID, err)
+		}
+		report.Succeeded = append(report.Succeeded, r.Succeeded...)
+		report.RolledBack = append(report.RolledBack, r.ID)
+		logrus.Infof("rolled back #%d %s", r.ID, r.Action)
+	}
+	return nil
+}
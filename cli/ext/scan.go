@@ -40,6 +40,28 @@ func (e *ExtensionInstall) Found() bool {
 	return e.Extension != nil
 }
 
+// Extension install states
+const (
+	StateOK     = "ok"     // installed and up to date
+	StateUpdate = "update" // installed version is older than catalog version
+	StateBroken = "broken" // control file of a DDL extension is missing
+)
+
+// State returns the install state of the extension: ok, update, or broken
+func (e *ExtensionInstall) State() string {
+	if e.Extension == nil {
+		return StateOK
+	}
+	// libraries are matched to extensions by name heuristics, so a missing library match is not a proof of breakage
+	if e.NeedDDL && e.ControlName == "" {
+		return StateBroken
+	}
+	if e.InstallVersion != "" && e.Version != "" && compareVersion(e.InstallVersion, e.Version) < 0 {
+		return StateUpdate
+	}
+	return StateOK
+}
+
 // ExtName returns the name of the extension
 func (e *ExtensionInstall) ExtName() string {
 	if e.Extension != nil {
@@ -232,7 +254,7 @@ func (pg *PostgresInstall) ExtensionInstallSummary() {
 
 	for _, ext := range extensions {
 		if !ext.Found() {
-			logrus.Warnf("extension %s not found in catalog", ext.ExtName())
+			continue
 		}
 		extDescHead := ext.Description()
 		if len(extDescHead) > 64 {
@@ -256,6 +278,22 @@ func (pg *PostgresInstall) ExtensionInstallSummary() {
 	}
 	w.Flush()
 
+	// extensions installed but unknown to the catalog are not managed by pig
+	if unknown := pg.UnknownExtensions(); len(unknown) > 0 {
+		fmt.Printf("\nNot in Catalog: %d extensions installed but unknown to pig catalog\n\n", len(unknown))
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Name\tVersion\tControl\tDescription")
+		fmt.Fprintln(w, "----\t-------\t-------\t---------------------")
+		for _, ext := range unknown {
+			extDescHead := ext.Description()
+			if len(extDescHead) > 64 {
+				extDescHead = extDescHead[:64] + "..."
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ext.ExtName(), ext.VersionString(), ext.ControlPath(), extDescHead)
+		}
+		w.Flush()
+	}
+
 	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	var unmatchedLibs []string
 	var encodingLibs []string
@@ -287,6 +325,17 @@ func (pg *PostgresInstall) ExtensionInstallSummary() {
 	w.Flush()
 }
 
+// UnknownExtensions returns installed extensions that are not found in the catalog
+func (pg *PostgresInstall) UnknownExtensions() []*ExtensionInstall {
+	var unknown []*ExtensionInstall
+	for _, ext := range pg.Extensions {
+		if !ext.Found() {
+			unknown = append(unknown, ext)
+		}
+	}
+	return unknown
+}
+
 func PrintInstalledPostgres() string {
 	if Installs == nil {
 		return ""
@@ -0,0 +1,62 @@
+package ext
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ExtensionJSONSchema returns the JSON Schema of Extension as emitted by json output, generated from struct tags
+func ExtensionJSONSchema() map[string]interface{} {
+	return jsonSchemaOf(reflect.TypeOf(Extension{}), "Extension")
+}
+
+// jsonSchemaOf generates a JSON Schema object for the given struct type via reflection
+func jsonSchemaOf(t reflect.Type, title string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = jsonSchemaType(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                title,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// jsonSchemaType maps go type to JSON Schema type definition
+func jsonSchemaType(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": jsonSchemaType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchemaType(t.Elem())}
+	case reflect.Ptr:
+		return jsonSchemaType(t.Elem())
+	}
+	return map[string]interface{}{}
+}
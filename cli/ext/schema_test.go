@@ -0,0 +1,32 @@
+package ext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtensionJSONSchema(t *testing.T) {
+	schema := ExtensionJSONSchema()
+	props, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("properties missing in schema")
+	}
+	tests := map[string]string{"id": "integer", "name": "string", "need_load": "boolean"}
+	for name, want := range tests {
+		prop, ok := props[name].(map[string]interface{})
+		if !ok {
+			t.Errorf("property %s missing in schema", name)
+			continue
+		}
+		if prop["type"] != want {
+			t.Errorf("property %s type = %v, want %s", name, prop["type"], want)
+		}
+	}
+	if _, ok := props["pg_ver"].(map[string]interface{})["items"]; !ok {
+		t.Errorf("array property pg_ver should have items")
+	}
+	required := schema["required"].([]string)
+	if !slices.Contains(required, "name") || slices.Contains(required, "conflicts") {
+		t.Errorf("unexpected required list: %v", required)
+	}
+}
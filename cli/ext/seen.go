@@ -0,0 +1,81 @@
+package ext
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"pig/internal/config"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// SeenFileName is the name of the file recording when each catalog extension is first seen
+const SeenFileName = "catalog-seen.yml"
+
+// SeenDateFormat is the date format used in seen file and --new-since
+const SeenDateFormat = "2006-01-02"
+
+// SeenFilePath returns the path to the catalog seen file
+func SeenFilePath() string {
+	return config.CachePath(SeenFileName)
+}
+
+// FirstSeen returns the first seen date of each extension in catalog (name -> date), and records new ones
+// extensions found on the first run are recorded with an empty date, as they are the baseline rather than new
+func (ec *ExtensionCatalog) FirstSeen() (map[string]string, error) {
+	seen := make(map[string]string)
+	data, err := os.ReadFile(SeenFilePath())
+	baseline := os.IsNotExist(err)
+	if err != nil && !baseline {
+		return nil, fmt.Errorf("failed to read seen file %s: %v", SeenFilePath(), err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, &seen); err != nil {
+			return nil, fmt.Errorf("failed to parse seen file %s: %v", SeenFilePath(), err)
+		}
+		if seen == nil {
+			seen = make(map[string]string)
+		}
+	}
+
+	today := time.Now().Format(SeenDateFormat)
+	changed := false
+	for _, ext := range ec.Extensions {
+		if _, ok := seen[ext.Name]; !ok {
+			if baseline {
+				seen[ext.Name] = ""
+			} else {
+				seen[ext.Name] = today
+				logrus.Debugf("new extension %s found in catalog", ext.Name)
+			}
+			changed = true
+		}
+	}
+	// the file is written only if some extension is seen for the first time, listing never touches it otherwise
+	if changed && config.CacheDir != "" {
+		if out, err := yaml.Marshal(seen); err == nil && !bytes.Equal(out, data) {
+			_ = os.MkdirAll(config.CacheDir, 0755)
+			if err := os.WriteFile(SeenFilePath(), out, 0644); err != nil {
+				logrus.Debugf("failed to write seen file %s: %v", SeenFilePath(), err)
+			}
+		}
+	}
+	return seen, nil
+}
+
+// NewExtensions filters extensions first seen (according to FirstSeen) at or after the given time
+func NewExtensions(exts []*Extension, seen map[string]string, since time.Time) []*Extension {
+	var result []*Extension
+	for _, ext := range exts {
+		date, ok := seen[ext.Name]
+		if !ok || date == "" {
+			continue
+		}
+		if t, err := time.Parse(SeenDateFormat, date); err == nil && !t.Before(since) {
+			result = append(result, ext)
+		}
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package ext
+
+import (
+	"os"
+	"pig/internal/config"
+	"testing"
+	"time"
+)
+
+func TestFirstSeenWritesOnChange(t *testing.T) {
+	savedDir := config.CacheDir
+	t.Cleanup(func() { config.CacheDir = savedDir })
+	config.CacheDir = t.TempDir()
+
+	ec := &ExtensionCatalog{Extensions: []*Extension{{Name: "pg_cron"}}}
+	if seen, err := ec.FirstSeen(); err != nil || seen["pg_cron"] != "" {
+		t.Fatalf("baseline FirstSeen() = %v, %v", seen, err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(SeenFilePath(), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ec.FirstSeen(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(SeenFilePath()); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("seen file should not be rewritten when nothing changed")
+	}
+
+	ec.Extensions = append(ec.Extensions, &Extension{Name: "vector"})
+	seen, err := ec.FirstSeen()
+	if err != nil || seen["vector"] != time.Now().Format(SeenDateFormat) {
+		t.Fatalf("FirstSeen() = %v, %v", seen, err)
+	}
+	since, _ := time.Parse(SeenDateFormat, seen["vector"])
+	if got := NewExtensions(ec.Extensions, seen, since); len(got) != 1 || got[0].Name != "vector" {
+		t.Errorf("NewExtensions() = %v, want vector", got)
+	}
+}
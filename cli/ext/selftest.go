@@ -0,0 +1,132 @@
+package ext
+
+import (
+	"fmt"
+	"path/filepath"
+	"pig/cli/repo"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"sort"
+)
+
+// SelfTestReport is the environment pig sees, used for bug reports
+type SelfTestReport struct {
+	PigVersion     string              `json:"pig_version"`
+	OSCode         string              `json:"os_code"`
+	OSArch         string              `json:"os_arch"`
+	OSType         string              `json:"os_type"`
+	OSVendor       string              `json:"os_vendor"`
+	OSVersion      string              `json:"os_version"`
+	User           string              `json:"user"`
+	PackageManager string              `json:"package_manager"`
+	ConfigDir      string              `json:"config_dir"`
+	CacheDir       string              `json:"cache_dir"`
+	CatalogSource  string              `json:"catalog_source"`
+	CatalogCount   int                 `json:"catalog_count"`
+	RepoFiles      []string            `json:"repo_files"`
+	Postgres       []*SelfTestPostgres `json:"postgres"`
+}
+
+// SelfTestPostgres is a detected PostgreSQL installation in self test report
+type SelfTestPostgres struct {
+	Version    string `json:"version"`
+	Major      int    `json:"major"`
+	Active     bool   `json:"active"`
+	PgConfig   string `json:"pg_config"`
+	BinPath    string `json:"bin_path"`
+	LibPath    string `json:"lib_path"`
+	ExtPath    string `json:"ext_path"`
+	Extensions int    `json:"extensions"`
+}
+
+// SelfTest collects the environment pig sees: os, package manager, postgres, catalog and repos
+func SelfTest() *SelfTestReport {
+	r := &SelfTestReport{
+		PigVersion:     config.PigVersion,
+		OSCode:         config.OSCode,
+		OSArch:         config.OSArch,
+		OSType:         config.OSType,
+		OSVendor:       config.OSVendor,
+		OSVersion:      config.OSVersionFull,
+		User:           config.CurrentUser,
+		PackageManager: Backend,
+		ConfigDir:      config.ConfigDir,
+		CacheDir:       config.CacheDir,
+		CatalogSource:  Catalog.DataPath,
+		CatalogCount:   len(Catalog.Extensions),
+		RepoFiles:      []string{},
+		Postgres:       []*SelfTestPostgres{},
+	}
+	if r.PackageManager == "" {
+		r.PackageManager = DefaultBackend()
+	}
+	if rm, err := repo.NewRepoManager(); err == nil && rm.RepoPattern != "" {
+		if files, err := filepath.Glob(rm.RepoPattern); err == nil {
+			r.RepoFiles = append(r.RepoFiles, files...)
+		}
+	}
+	if !Inited {
+		_ = DetectPostgres()
+	}
+	for _, pg := range Installs {
+		r.Postgres = append(r.Postgres, newSelfTestPostgres(pg))
+	}
+	if Active != nil && PathMap[Active.PgConfigPath] == nil {
+		r.Postgres = append(r.Postgres, newSelfTestPostgres(Active))
+	}
+	sort.Slice(r.Postgres, func(i, j int) bool { return r.Postgres[i].Major > r.Postgres[j].Major })
+	return r
+}
+
+func newSelfTestPostgres(pg *PostgresInstall) *SelfTestPostgres {
+	return &SelfTestPostgres{
+		Version:    pg.Version,
+		Major:      pg.MajorVersion,
+		Active:     pg == Active,
+		PgConfig:   pg.PgConfig,
+		BinPath:    pg.BinPath,
+		LibPath:    pg.LibPath,
+		ExtPath:    pg.ExtPath,
+		Extensions: len(pg.Extensions),
+	}
+}
+
+// PrintSelfTest prints the self test report in text or json format
+func PrintSelfTest(format string) error {
+	r := SelfTest()
+	if format == "json" {
+		return utils.PrintJSON(r)
+	}
+	utils.PadKV("Pig Version", r.PigVersion)
+	utils.PadKV("OS", fmt.Sprintf("%s (%s %s) %s", r.OSCode, r.OSVendor, r.OSVersion, r.OSArch))
+	utils.PadKV("OS Type", r.OSType)
+	utils.PadKV("User", r.User)
+	utils.PadKV("Package Manager", r.PackageManager)
+	utils.PadKV("Config Dir", r.ConfigDir)
+	utils.PadKV("Cache Dir", r.CacheDir)
+	utils.PadKV("Catalog", fmt.Sprintf("%d extensions from %s", r.CatalogCount, r.CatalogSource))
+	fmt.Println("\nRepo Files:")
+	if len(r.RepoFiles) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, f := range r.RepoFiles {
+		fmt.Printf("  - %s\n", f)
+	}
+	fmt.Println("\nPostgreSQL:")
+	if len(r.Postgres) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, pg := range r.Postgres {
+		active := " "
+		if pg.Active {
+			active = "*"
+		}
+		fmt.Printf("%s %s\n", active, pg.Version)
+		fmt.Printf("    pg_config  : %s\n", pg.PgConfig)
+		fmt.Printf("    bin        : %s\n", pg.BinPath)
+		fmt.Printf("    lib        : %s\n", pg.LibPath)
+		fmt.Printf("    extension  : %s (%d installed)\n", pg.ExtPath, pg.Extensions)
+	}
+	fmt.Println()
+	return nil
+}
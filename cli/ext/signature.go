@@ -0,0 +1,213 @@
+package ext
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"pig/internal/config"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	CheckSignatures = true  // enforce signature verification of signed repos (gpg keyed on EL, apt on Debian)
+	AllowUnsigned   = false // explicitly allow unsigned packages, overrides CheckSignatures
+)
+
+// YumReposDir is the dir of rpm repo files, repos with a gpgkey there are signature checked on install
+var YumReposDir = "/etc/yum.repos.d"
+
+// CheckSignatureOptions validates signature options against other install options
+func CheckSignatureOptions() error {
+	if RepoURL != "" && CheckSignatures && !AllowUnsigned {
+		return fmt.Errorf("packages from --repo-url can not be verified, add --allow-unsigned to install them")
+	}
+	return nil
+}
+
+// signatureArgs returns package manager args that enforce or skip signature verification
+func signatureArgs(osType string) []string {
+	switch {
+	case AllowUnsigned:
+		if osType == config.DistroEL {
+			return []string{"--nogpgcheck"}
+		}
+		if osType == config.DistroDEB {
+			return []string{"--allow-unauthenticated"}
+		}
+	case CheckSignatures:
+		if osType == config.DistroEL {
+			// pig repos have no gpgkey, so gpgcheck is enforced on keyed repos only instead of *.gpgcheck
+			signed, _ := rpmRepoKeys(YumReposDir)
+			var args []string
+			for _, repo := range signed {
+				args = append(args, "--setopt="+repo+".gpgcheck=1")
+			}
+			return args
+		}
+		if osType == config.DistroDEB {
+			return []string{"-o", "APT::Get::AllowUnauthenticated=false", "-o", "Acquire::AllowInsecureRepositories=false"}
+		}
+	}
+	return nil
+}
+
+// rpmRepoKeys parses repo files in dir, and returns ids of enabled repos with and without a gpgkey
+func rpmRepoKeys(dir string) (signed []string, unsigned []string) {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.repo"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var id string
+		enabled, keyed := true, false
+		flush := func() {
+			switch {
+			case id == "" || !enabled:
+			case keyed:
+				signed = append(signed, id)
+			default:
+				unsigned = append(unsigned, id)
+			}
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+				flush()
+				id, enabled, keyed = strings.Trim(line, "[]"), true, false
+				continue
+			}
+			key, value, ok := strings.Cut(line, "=")
+			if !ok || strings.HasPrefix(line, "#") {
+				continue
+			}
+			switch strings.TrimSpace(key) {
+			case "enabled":
+				enabled = strings.TrimSpace(value) != "0"
+			case "gpgkey":
+				keyed = strings.TrimSpace(value) != ""
+			}
+		}
+		flush()
+	}
+	return signed, unsigned
+}
+
+// debTrustedRe matches apt sources that skip signature verification
+var debTrustedRe = regexp.MustCompile(`(?i)(\[[^]]*\btrusted=yes\b[^]]*\]|^\s*trusted:\s*yes\b)`)
+
+// unsignedDebSources returns apt source files that mark repos as trusted=yes, which apt never verifies
+func unsignedDebSources() []string {
+	files := []string{"/etc/apt/sources.list"}
+	for _, pattern := range []string{"/etc/apt/sources.list.d/*.list", "/etc/apt/sources.list.d/*.sources"} {
+		matches, _ := filepath.Glob(pattern)
+		files = append(files, matches...)
+	}
+	var unsigned []string
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "#") && debTrustedRe.MatchString(line) {
+				unsigned = append(unsigned, file)
+				break
+			}
+		}
+		f.Close()
+	}
+	return unsigned
+}
+
+// warnUnsignedSources warns about repos that bypass signature verification even if it is enforced
+func warnUnsignedSources() {
+	if AllowUnsigned {
+		logrus.Warnf("--allow-unsigned is set, packages are installed without signature verification")
+		return
+	}
+	if !CheckSignatures {
+		return
+	}
+	if config.OSType == config.DistroEL {
+		if _, unsigned := rpmRepoKeys(YumReposDir); len(unsigned) > 0 {
+			logrus.Infof("repos without gpgkey are not signature verified: %s", strings.Join(unsigned, ", "))
+		}
+		return
+	}
+	if config.OSType != config.DistroDEB {
+		return
+	}
+	if files := unsignedDebSources(); len(files) > 0 {
+		logrus.Warnf("apt sources marked trusted=yes are not signature verified: %s", strings.Join(files, ", "))
+	}
+	if UseCache {
+		logrus.Warnf("cached deb files are installed as local files, apt does not verify their signatures")
+	}
+}
+
+// rpmSignerRe extracts the key id of rpm signature
+var rpmSignerRe = regexp.MustCompile(`Key ID ([0-9a-fA-F]+)`)
+
+// packageSigner returns who signed an installed package: the rpm key id, or the signed apt repo it came from
+func packageSigner(pkg string) string {
+	switch config.OSType {
+	case config.DistroEL:
+		out, err := exec.Command("rpm", "-q", "--qf", "%{SIGPGP:pgpsig}|%{RSAHEADER:pgpsig}\n", pkg).Output()
+		if err != nil {
+			return "unknown"
+		}
+		return parseRpmSigner(string(out))
+	case config.DistroDEB:
+		name, _, _ := strings.Cut(pkg, "=")
+		out, err := exec.Command("apt-cache", "policy", name).Output()
+		if err != nil {
+			return "unknown"
+		}
+		return parseAptOrigin(string(out))
+	}
+	return "unknown"
+}
+
+// parseRpmSigner parses the signature query output of rpm
+func parseRpmSigner(out string) string {
+	if m := rpmSignerRe.FindStringSubmatch(out); m != nil {
+		return "key " + strings.ToLower(m[1])
+	}
+	return "unsigned"
+}
+
+// parseAptOrigin parses apt-cache policy output, returns the repo of installed version whose release is signed
+func parseAptOrigin(out string) string {
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "***") {
+			continue
+		}
+		// source lines of a version are indented deeper than version lines
+		for _, next := range lines[i+1:] {
+			if !strings.HasPrefix(next, "        ") {
+				break
+			}
+			if fields := strings.Fields(next); len(fields) >= 3 && strings.Contains(fields[1], "://") {
+				return "release of repo " + fields[1] + " " + fields[2]
+			}
+		}
+		return "local"
+	}
+	return "unknown"
+}
+
+// logSigners logs the signer of installed packages
+func logSigners(pkgs []string) {
+	for _, pkg := range pkgs {
+		logrus.Infof("package %s signed by %s", pkg, packageSigner(pkg))
+	}
+}
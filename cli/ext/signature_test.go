@@ -0,0 +1,64 @@
+package ext
+
+import (
+	"os"
+	"path/filepath"
+	"pig/internal/config"
+	"slices"
+	"testing"
+)
+
+func TestSignatureArgs(t *testing.T) {
+	defer func(check, allow bool) { CheckSignatures, AllowUnsigned = check, allow }(CheckSignatures, AllowUnsigned)
+	defer func(dir string) { YumReposDir = dir }(YumReposDir)
+	YumReposDir = t.TempDir()
+	repo := "[pgdg16]\nname=PGDG 16\ngpgcheck=1\ngpgkey=file:///etc/pki/rpm-gpg/PGDG-RPM-GPG-KEY\n\n[pigsty-pgsql]\nname=Pigsty PGSQL\ngpgcheck=0\n\n[pgdg-extras]\nenabled=0\ngpgkey=file:///etc/pki/rpm-gpg/PGDG-RPM-GPG-KEY\n"
+	if err := os.WriteFile(filepath.Join(YumReposDir, "pig.repo"), []byte(repo), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if signed, unsigned := rpmRepoKeys(YumReposDir); !slices.Equal(signed, []string{"pgdg16"}) || !slices.Equal(unsigned, []string{"pigsty-pgsql"}) {
+		t.Errorf("rpmRepoKeys() = %v, %v", signed, unsigned)
+	}
+
+	CheckSignatures, AllowUnsigned = true, false
+	if args := signatureArgs(config.DistroEL); !slices.Equal(args, []string{"--setopt=pgdg16.gpgcheck=1"}) {
+		t.Errorf("el args should enforce gpgcheck on keyed repos only: %v", args)
+	}
+	if args := signatureArgs(config.DistroDEB); !slices.Contains(args, "APT::Get::AllowUnauthenticated=false") {
+		t.Errorf("deb args should refuse unauthenticated packages: %v", args)
+	}
+	AllowUnsigned = true
+	if args := signatureArgs(config.DistroEL); !slices.Equal(args, []string{"--nogpgcheck"}) {
+		t.Errorf("el args with --allow-unsigned: %v", args)
+	}
+	CheckSignatures, AllowUnsigned = false, false
+	if args := signatureArgs(config.DistroDEB); args != nil {
+		t.Errorf("deb args without signature check: %v", args)
+	}
+}
+
+func TestParseSigner(t *testing.T) {
+	rpm := "RSA/SHA256, Mon 02 Dec 2024 10:00:00 AM UTC, Key ID 9e8e5a7cb1b0a8f4|(none)\n"
+	if got := parseRpmSigner(rpm); got != "key 9e8e5a7cb1b0a8f4" {
+		t.Errorf("parseRpmSigner() = %q", got)
+	}
+	if got := parseRpmSigner("(none)|(none)\n"); got != "unsigned" {
+		t.Errorf("parseRpmSigner() = %q, want unsigned", got)
+	}
+	apt := `postgresql-17-cron:
+  Installed: 1.6.4-1.pgdg120+1
+  Candidate: 1.6.4-1.pgdg120+1
+  Version table:
+     1.6.5-1.pgdg120+1 500
+        500 http://apt.postgresql.org/pub/repos/apt bookworm-pgdg/main amd64 Packages
+ *** 1.6.4-1.pgdg120+1 100
+        500 https://repo.pigsty.io/apt/pgsql/bookworm bookworm/main amd64 Packages
+        100 /var/lib/dpkg/status
+`
+	if got := parseAptOrigin(apt); got != "release of repo https://repo.pigsty.io/apt/pgsql/bookworm bookworm/main" {
+		t.Errorf("parseAptOrigin() = %q", got)
+	}
+	if got := parseAptOrigin("foo:\n  Installed: 1.0\n  Version table:\n *** 1.0 100\n        100 /var/lib/dpkg/status\n"); got != "local" {
+		t.Errorf("parseAptOrigin() = %q, want local", got)
+	}
+}
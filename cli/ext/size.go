@@ -0,0 +1,150 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+)
+
+// ExtensionSize hold the disk usage of an installed extension
+type ExtensionSize struct {
+	Name     string   `json:"name"`
+	Packages []string `json:"packages"`
+	Size     int64    `json:"size"`
+	Source   string   `json:"source"` // package, shared (counted by another extension) or file
+}
+
+// ExtensionSizes returns disk usage of installed extensions on designated PostgreSQL, sorted by size desc
+func ExtensionSizes() ([]*ExtensionSize, error) {
+	if Postgres == nil {
+		return nil, fmt.Errorf("no PostgreSQL specified and not active PostgreSQL found")
+	}
+	pkgSizes := queryPackageSizes()
+	counted := make(map[string]bool) // a package may be shared by multiple extensions
+	seen := make(map[string]bool)
+	sizes := make([]*ExtensionSize, 0, len(Postgres.Extensions))
+	for _, ei := range Postgres.Extensions {
+		name := ei.ExtName()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		es := &ExtensionSize{Name: name, Source: "file"}
+		if ei.Extension != nil && ei.Extension.Repo != "CONTRIB" {
+			for _, pattern := range processPkgName(ei.Extension.PackageName(Postgres.MajorVersion), Postgres.MajorVersion) {
+				for pkg, size := range pkgSizes {
+					if matched, _ := filepath.Match(pattern, pkg); matched {
+						es.Packages = append(es.Packages, pkg)
+						if !counted[pkg] {
+							es.Size += size
+							counted[pkg] = true
+						}
+					}
+				}
+			}
+		}
+		if len(es.Packages) > 0 {
+			es.Source = "package"
+			if es.Size == 0 {
+				es.Source = "shared"
+			}
+			sort.Strings(es.Packages)
+		} else {
+			es.Size = ei.FileSize()
+		}
+		sizes = append(sizes, es)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Size == sizes[j].Size {
+			return sizes[i].Name < sizes[j].Name
+		}
+		return sizes[i].Size > sizes[j].Size
+	})
+	return sizes, nil
+}
+
+// FileSize returns the total size of control, sql script, and shared library files of the extension
+func (ei *ExtensionInstall) FileSize() int64 {
+	if ei.Postgres == nil {
+		return 0
+	}
+	var total int64
+	var files []string
+	if ei.ControlName != "" && ei.Postgres.ExtPath != "" {
+		files = append(files, ei.ControlPath())
+		scripts, _ := filepath.Glob(filepath.Join(ei.Postgres.ExtPath, ei.ControlName+"--*.sql"))
+		files = append(files, scripts...)
+	}
+	for _, lib := range ei.SharedLibraries() {
+		files = append(files, filepath.Join(ei.Postgres.LibPath, lib))
+	}
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// queryPackageSizes returns installed package sizes in bytes (package name -> size) from package manager
+func queryPackageSizes() map[string]int64 {
+	var cmd *exec.Cmd
+	var unit int64 = 1
+	switch config.OSType {
+	case config.DistroEL:
+		cmd = exec.Command("rpm", "-qa", "--queryformat", "%{NAME}\t%{SIZE}\n")
+	case config.DistroDEB:
+		cmd = exec.Command("dpkg-query", "-W", "-f", "${Package}\t${Installed-Size}\n")
+		unit = 1024 // dpkg installed size is in KiB
+	default:
+		return nil
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		logrus.Debugf("failed to query package sizes: %v", err)
+		return nil
+	}
+	sizes := make(map[string]int64)
+	for _, line := range strings.Split(string(output), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "\t")
+		if len(parts) != 2 {
+			continue
+		}
+		if size, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+			sizes[parts[0]] = size * unit
+		}
+	}
+	return sizes
+}
+
+// PrintExtensionSizes prints disk usage of installed extensions in table or json format
+func PrintExtensionSizes(format string) error {
+	sizes, err := ExtensionSizes()
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return utils.PrintJSON(sizes)
+	}
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tSize\tSource\tPackages")
+	fmt.Fprintln(w, "----\t----\t------\t--------")
+	for _, es := range sizes {
+		total += es.Size
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", es.Name, humanize.Bytes(uint64(es.Size)), es.Source, strings.Join(es.Packages, ", "))
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows) (Total: %s)\n\n", len(sizes), humanize.Bytes(uint64(total)))
+	return nil
+}
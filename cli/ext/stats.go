@@ -0,0 +1,111 @@
+package ext
+
+import (
+	"fmt"
+	"os"
+	"pig/internal/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// CatalogStats is the aggregated coverage metrics of the extension catalog
+type CatalogStats struct {
+	Source      string         `json:"source"`
+	Total       int            `json:"total"`
+	Categories  map[string]int `json:"categories"`
+	Licenses    map[string]int `json:"licenses"`
+	PgVersions  map[string]int `json:"pg_versions"`
+	RpmOnly     int            `json:"rpm_only"`
+	DebOnly     int            `json:"deb_only"`
+	RpmAndDeb   int            `json:"rpm_and_deb"`
+	NoPackage   int            `json:"no_package"`
+	WithBadCase int            `json:"with_bad_case"`
+	WithComment int            `json:"with_comment"`
+}
+
+// Stats computes the coverage metrics of catalog extensions
+func (ec *ExtensionCatalog) Stats() *CatalogStats {
+	s := &CatalogStats{
+		Source:     ec.DataPath,
+		Total:      len(ec.Extensions),
+		Categories: make(map[string]int),
+		Licenses:   make(map[string]int),
+		PgVersions: make(map[string]int),
+	}
+	for _, ext := range ec.Extensions {
+		s.Categories[ext.Category]++
+		s.Licenses[NormalizeLicense(ext.License)]++
+		for _, v := range ext.PgVer {
+			s.PgVersions[v]++
+		}
+		switch rpm, deb := ext.RpmPkg != "", ext.DebPkg != ""; {
+		case rpm && deb:
+			s.RpmAndDeb++
+		case rpm:
+			s.RpmOnly++
+		case deb:
+			s.DebOnly++
+		default:
+			s.NoPackage++
+		}
+		if len(ext.BadCase) > 0 {
+			s.WithBadCase++
+		}
+		if ext.Comment != "" {
+			s.WithComment++
+		}
+	}
+	return s
+}
+
+// PrintCatalogStats prints the catalog coverage metrics in table or json format
+func (ec *ExtensionCatalog) PrintCatalogStats(format string) error {
+	s := ec.Stats()
+	if format == "json" {
+		return utils.PrintJSON(s)
+	}
+	utils.PadKV("Catalog", s.Source)
+	utils.PadKV("Extensions", strconv.Itoa(s.Total))
+	utils.PadKV("RPM & DEB", strconv.Itoa(s.RpmAndDeb))
+	utils.PadKV("RPM Only", strconv.Itoa(s.RpmOnly))
+	utils.PadKV("DEB Only", strconv.Itoa(s.DebOnly))
+	utils.PadKV("No Package", strconv.Itoa(s.NoPackage))
+	utils.PadKV("With BadCase", strconv.Itoa(s.WithBadCase))
+	utils.PadKV("With Comment", strconv.Itoa(s.WithComment))
+	fmt.Println()
+	printStatCounts("PG Version", s.PgVersions, func(a, b string) bool {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x > y
+	})
+	printStatCounts("Category", s.Categories, nil)
+	printStatCounts("License", s.Licenses, nil)
+	return nil
+}
+
+// printStatCounts prints a key / count table, sorted by given less function or count desc
+func printStatCounts(title string, counts map[string]int, less func(a, b string) bool) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if less != nil {
+			return less(keys[i], keys[j])
+		}
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tCount\n", title)
+	fmt.Fprintf(w, "%s\t-----\n", strings.Repeat("-", len(title)))
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%d\n", k, counts[k])
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows)\n\n", len(keys))
+}
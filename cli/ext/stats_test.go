@@ -0,0 +1,22 @@
+package ext
+
+import "testing"
+
+func TestCatalogStats(t *testing.T) {
+	ec := &ExtensionCatalog{Extensions: []*Extension{
+		{Name: "a", Category: "GIS", License: "PostgreSQL License", PgVer: []string{"17", "16"}, RpmPkg: "a_$v", DebPkg: "postgresql-$v-a"},
+		{Name: "b", Category: "GIS", License: "MIT", PgVer: []string{"17"}, RpmPkg: "b_$v", BadCase: []string{"el8"}},
+		{Name: "c", Category: "FDW", License: "PostgreSQL", PgVer: []string{"16"}, DebPkg: "postgresql-$v-c", Comment: "note"},
+		{Name: "d", Category: "FDW", License: "MIT"},
+	}}
+	s := ec.Stats()
+	if s.Total != 4 || s.RpmAndDeb != 1 || s.RpmOnly != 1 || s.DebOnly != 1 || s.NoPackage != 1 {
+		t.Errorf("package coverage = %+v", s)
+	}
+	if s.Categories["GIS"] != 2 || s.Licenses["PostgreSQL"] != 2 || s.PgVersions["17"] != 2 || s.PgVersions["16"] != 2 {
+		t.Errorf("counts = %v %v %v", s.Categories, s.Licenses, s.PgVersions)
+	}
+	if s.WithBadCase != 1 || s.WithComment != 1 {
+		t.Errorf("bad case = %d, comment = %d", s.WithBadCase, s.WithComment)
+	}
+}
@@ -3,31 +3,44 @@ package ext
 import (
 	"fmt"
 	"os"
+	"pig/internal/utils"
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
-// ExtensionStatus prints the status of installed extensions
-func ExtensionStatus(contrib bool) {
-	PostgresInstallSummary()
+// ExtensionStatus prints the status of installed extensions, with load/superuser/schema columns if wide
+func ExtensionStatus(d *Detection, contrib bool, wide bool) {
+	PrintPostgresSummary(d)
 	if Postgres == nil {
 		logrus.Errorf("no PostgreSQL specified and not active PostgreSQL found")
 		fmt.Printf("hint: use -v or -p to specify PostgreSQL installation\n\n")
 		return
 	}
+	exts, repocount := statusExtensions(contrib)
+	printExtensionSummary(repocount, len(Postgres.Extensions))
+	ages := statusAges(exts)
+	if wide {
+		tabulateExtensionsWide(exts, ages)
+		return
+	}
+	tabulateExtensions(exts, ages)
+}
 
+// statusExtensions returns installed catalog extensions sorted by id, and the count of extensions by repo
+func statusExtensions(contrib bool) ([]*ExtensionInstall, map[string]int) {
 	// Count extensions by repo
-	var exts []*Extension
+	var exts []*ExtensionInstall
 	var notFound []string
 	repocount := map[string]int{"CONTRIB": 0, "PGDG": 0, "PIGSTY": 0}
 	for _, ext := range Postgres.Extensions {
-		extInfo := Catalog.ExtNameMap[ext.Name]
+		extInfo := ext.Extension
 		if extInfo == nil {
-			logrus.Infof("Extension: %s (not found in catalog)", ext.Name)
-			notFound = append(notFound, ext.Name)
+			logrus.Infof("Extension: %s (not found in catalog)", ext.ExtName())
+			notFound = append(notFound, ext.ExtName())
 			continue
 		}
 		if extInfo.RepoName() != "" {
@@ -39,7 +52,7 @@ func ExtensionStatus(contrib bool) {
 		if !contrib && extInfo.Repo == "CONTRIB" {
 			continue
 		}
-		exts = append(exts, extInfo)
+		exts = append(exts, ext)
 	}
 	sort.Slice(exts, func(i, j int) bool {
 		return exts[i].ID < exts[j].ID
@@ -48,9 +61,85 @@ func ExtensionStatus(contrib bool) {
 	if len(notFound) > 0 {
 		logrus.Warnf("not found in catalog : %s", strings.Join(notFound, ", "))
 	}
+	return exts, repocount
+}
 
-	printExtensionSummary(repocount, len(Postgres.Extensions))
-	tabulateExtensions(exts)
+// statusAges returns install times of extensions if StatusAge is set, nil otherwise
+func statusAges(exts []*ExtensionInstall) map[string]time.Time {
+	if !StatusAge {
+		return nil
+	}
+	ages, err := ExtensionInstallTimes(exts, Postgres.MajorVersion)
+	if err != nil {
+		logrus.Warnf("failed to get extension install time: %v", err)
+		return map[string]time.Time{}
+	}
+	return ages
+}
+
+// withAge inserts an Installed column before the last (description) column if ages is not nil
+func withAge(rows [][]string, exts []*ExtensionInstall, ages map[string]time.Time) [][]string {
+	if ages == nil {
+		return rows
+	}
+	now := time.Now()
+	for i, row := range rows {
+		age := "-"
+		switch {
+		case i == 0:
+			age = "Installed"
+		case i == 1:
+			age = "---------"
+		case !ages[exts[i-2].Name].IsZero():
+			age = humanAge(ages[exts[i-2].Name], now)
+		}
+		last := len(row) - 1
+		rows[i] = append(append(row[:last:last], age), row[last])
+	}
+	return rows
+}
+
+// StatusItem is an installed extension in json status output
+type StatusItem struct {
+	Name        string     `json:"name"`
+	State       string     `json:"state"`
+	Version     string     `json:"version"`
+	Category    string     `json:"category"`
+	Repo        string     `json:"repo"`
+	Package     string     `json:"package"`
+	InstalledAt *time.Time `json:"installed_at,omitempty"`
+}
+
+// ExtensionStatusJSON prints the status of installed extensions in json, with install time if StatusAge is set
+func ExtensionStatusJSON(contrib bool) error {
+	if Postgres == nil {
+		return fmt.Errorf("no PostgreSQL specified and not active PostgreSQL found")
+	}
+	exts, _ := statusExtensions(contrib)
+	ages := statusAges(exts)
+	items := make([]*StatusItem, 0, len(exts))
+	for _, ei := range exts {
+		item := &StatusItem{Name: ei.Name, State: ei.State(), Version: ei.ActiveVersion(), Category: ei.Category,
+			Repo: ei.RepoName(), Package: ei.PackageName(Postgres.MajorVersion)}
+		if t, ok := ages[ei.Name]; ok {
+			item.InstalledAt = &t
+		}
+		items = append(items, item)
+	}
+	return utils.PrintJSON(items)
+}
+
+// ExtensionStatusTemplate prints installed extensions one per line with the given template, as TabulteTemplate
+func ExtensionStatusTemplate(contrib bool, text string) error {
+	if Postgres == nil {
+		return fmt.Errorf("no PostgreSQL specified and not active PostgreSQL found")
+	}
+	exts, _ := statusExtensions(contrib)
+	data := make([]*Extension, 0, len(exts))
+	for _, ei := range exts {
+		data = append(data, ei.Extension)
+	}
+	return TabulteTemplate(text, data)
 }
 
 func printExtensionSummary(repocount map[string]int, totalExtensions int) {
@@ -67,18 +156,150 @@ func printExtensionSummary(repocount map[string]int, totalExtensions int) {
 	fmt.Println(extSummary)
 }
 
-func tabulateExtensions(exts []*Extension) {
+func tabulateExtensions(exts []*ExtensionInstall, ages map[string]time.Time) {
+	rows := [][]string{
+		{"Name", "State", "Version", "Cate", "Flags", "License", "Repo", "Package", "Description"},
+		{"----", "-----", "-------", "----", "------", "-------", "------", "------------", "---------------------"},
+	}
+	for _, ei := range exts {
+		ext := ei.Extension
+		rows = append(rows, []string{ext.Name, stateMarker(ei.State()), ei.ActiveVersion(), ext.Category, ext.GetFlag(), ext.License, ext.RepoName(), ext.PackageName(Postgres.MajorVersion), ext.EnDesc})
+	}
+	writeTable(os.Stdout, withAge(rows, exts, ages), 2, tableWidth())
+
+	fmt.Printf("\n(%d Rows) (State: [OK] up to date, [UPD] updatable, [ERR] broken) (Flags: b = HasBin, d = HasDDL, s = HasSolib, l = NeedLoad, t = Trusted, r = Relocatable, x = Unknown)\n\n", len(exts))
+}
+
+func tabulateExtensionsWide(exts []*ExtensionInstall, ages map[string]time.Time) {
+	preload, err := QueryPreloadLibraries()
+	if err != nil {
+		logrus.Debugf("failed to query shared_preload_libraries: %v", err)
+	}
+	rows := [][]string{
+		{"Name", "State", "Version", "Cate", "Load", "SuperUser", "Schema", "Reloc", "Repo", "Package", "Description"},
+		{"----", "-----", "-------", "----", "----", "---------", "------", "-----", "----", "-------", "-----------"},
+	}
+	for _, ei := range exts {
+		ext := ei.Extension
+		load := "No"
+		if ext.NeedLoad {
+			switch {
+			case preload == nil:
+				load = "Yes (unknown)"
+			case preload[ext.Name]:
+				load = "Yes (loaded)"
+			default:
+				load = "Yes (not loaded)"
+			}
+		}
+		superuser := "N/A"
+		if ext.Trusted == "t" {
+			superuser = "No"
+		} else if ext.Trusted == "f" {
+			superuser = "Yes"
+		}
+		schema := "-"
+		if len(ext.Schemas) > 0 {
+			schema = strings.Join(ext.Schemas, ",")
+		}
+		rows = append(rows, []string{ext.Name, stateMarker(ei.State()), ei.ActiveVersion(), ext.Category,
+			load, superuser, schema, ext.GetBool("relocatable"), ext.RepoName(), ext.PackageName(Postgres.MajorVersion), ext.EnDesc})
+	}
+	writeTable(os.Stdout, withAge(rows, exts, ages), 2, tableWidth())
+	fmt.Printf("\n(%d Rows) (State: [OK] up to date, [UPD] updatable, [ERR] broken) (Load: need shared_preload_libraries, and whether loaded)\n\n", len(exts))
+}
+
+// stateMarker returns the plain marker of install state, colored if color is enabled
+func stateMarker(state string) string {
+	switch state {
+	case StateUpdate:
+		return utils.Colorize(utils.ColorYellow, "[UPD]")
+	case StateBroken:
+		return utils.Colorize(utils.ColorRed, "[ERR]")
+	default:
+		return utils.Colorize(utils.ColorGreen, "[OK]")
+	}
+}
+
+// DatabaseStatus prints extensions created in given database, with installed and default versions
+func DatabaseStatus(dbname string) error {
+	exts, err := QueryDatabaseExtensions(dbname)
+	if err != nil {
+		return fmt.Errorf("failed to query extensions of database %s: %v", dbname, err)
+	}
+	var updates []string
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Name\tVersion\tCate\tFlags\tLicense\tRepo\tPackage\tDescription")
-	fmt.Fprintln(w, "----\t-------\t----\t------\t-------\t------\t------------\t---------------------")
-	for _, ext := range exts {
-		desc := ext.EnDesc
-		if len(desc) > 64 {
-			desc = desc[:64]
+	fmt.Fprintln(w, "Name\tState\tVersion\tDefault\tSchema\tRepo")
+	fmt.Fprintln(w, "----\t-----\t-------\t-------\t------\t----")
+	for _, de := range exts {
+		repo := "-"
+		if e, ok := Catalog.ExtNameMap[de.Name]; ok && e.RepoName() != "" {
+			repo = e.RepoName()
+		}
+		defaultVer := de.DefaultVersion
+		if defaultVer == "" {
+			defaultVer = "-"
+		}
+		if de.State() == StateUpdate {
+			updates = append(updates, fmt.Sprintf("ALTER EXTENSION %s UPDATE;", quoteIdent(de.Name)))
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", ext.Name, ext.Version, ext.Category, ext.GetFlag(), ext.License, ext.RepoName(), ext.PackageName(Postgres.MajorVersion), desc)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", de.Name, stateMarker(de.State()), de.Version, defaultVer, de.Schema, repo)
 	}
 	w.Flush()
+	fmt.Printf("\n(%d Rows) (Database: %s) (State: [OK] up to date, [UPD] need ALTER EXTENSION UPDATE, [ERR] package files missing)\n\n", len(exts), dbname)
+	if len(updates) > 0 {
+		fmt.Printf("run in database %s to update:\n  %s\n\n", dbname, strings.Join(updates, "\n  "))
+	}
+	return nil
+}
+
+// DiffDatabaseExtensions compares enabled extensions among given databases of the designated PostgreSQL
+func DiffDatabaseExtensions(dbs []string) error {
+	if len(dbs) < 2 {
+		return fmt.Errorf("at least two databases are required to compare, got %d", len(dbs))
+	}
+	dbExts := make([]map[string]string, len(dbs))
+	nameSet := make(map[string]bool)
+	for i, db := range dbs {
+		exts, err := QueryExtensions(db)
+		if err != nil {
+			return fmt.Errorf("failed to query extensions of database %s: %v", db, err)
+		}
+		dbExts[i] = exts
+		for name := range exts {
+			nameSet[name] = true
+		}
+	}
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	fmt.Printf("\n(%d Rows) (Flags: b = HasBin, d = HasDDL, s = HasSolib, l = NeedLoad, t = Trusted, r = Relocatable, x = Unknown)\n\n", len(exts))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Extension\t%s\tState\n", strings.Join(dbs, "\t"))
+	fmt.Fprintf(w, "---------\t%s\t-----\n", strings.Repeat("-------\t", len(dbs)-1)+"-------")
+	drift := 0
+	for _, name := range names {
+		versions := make([]string, len(dbs))
+		state := "same"
+		for i, exts := range dbExts {
+			if v, ok := exts[name]; ok {
+				versions[i] = v
+			} else {
+				versions[i] = "-"
+				state = "missing"
+			}
+			if state == "same" && versions[i] != versions[0] {
+				state = "version"
+			}
+		}
+		if state != "same" {
+			drift++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, strings.Join(versions, "\t"), state)
+	}
+	w.Flush()
+	fmt.Printf("\n(%d Rows) (%d Drift) (State: same|version|missing)\n\n", len(names), drift)
+	return nil
 }
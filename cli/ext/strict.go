@@ -0,0 +1,154 @@
+package ext
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"pig/internal/config"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StrictVersion cross-checks repos for an actual package matching the postgres minor version
+// before listing an extension as available
+var StrictVersion bool
+
+// repoPackageNames returns names of all packages available in enabled repos
+func repoPackageNames() (map[string]bool, error) {
+	var cmd *exec.Cmd
+	switch config.OSType {
+	case config.DistroEL:
+		cmd = exec.Command("dnf", "repoquery", "-q", "--qf", "%{name}\n")
+	case config.DistroDEB:
+		cmd = exec.Command("apt-cache", "pkgnames")
+	default:
+		return nil, fmt.Errorf("strict version check is not supported on %s", config.OSType)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repo packages: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// hasRepoPackage tells whether the main package of the extension for given pg version is found in repo packages
+func hasRepoPackage(ext *Extension, pgVer int, repo map[string]bool) bool {
+	pkgs := processPkgName(ext.PackageName(pgVer), pgVer)
+	if len(pkgs) == 0 {
+		return false
+	}
+	if repo[pkgs[0]] {
+		return true
+	}
+	if strings.ContainsAny(pkgs[0], "*?[") {
+		for name := range repo {
+			if ok, _ := path.Match(pkgs[0], name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// repoMainPackages returns the main repo package names of available extensions for given pg version
+func repoMainPackages(data []*Extension, pgVer int, repo map[string]bool) []string {
+	var names []string
+	for _, ext := range data {
+		if ext.GetStatus(pgVer) != "avail" {
+			continue
+		}
+		pkgs := processPkgName(ext.PackageName(pgVer), pgVer)
+		if len(pkgs) == 0 {
+			continue
+		}
+		if repo[pkgs[0]] {
+			names = append(names, pkgs[0])
+			continue
+		}
+		if strings.ContainsAny(pkgs[0], "*?[") {
+			for name := range repo {
+				if ok, _ := path.Match(pkgs[0], name); ok {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// queryPackageRequires dumps the dependencies of given repo packages, each package starts with a
+// "Package: name" line (apt-cache show) or a "@name" line (dnf repoquery)
+func queryPackageRequires(pkgs []string) (string, error) {
+	var cmd *exec.Cmd
+	switch config.OSType {
+	case config.DistroEL:
+		cmd = exec.Command("dnf", append([]string{"repoquery", "-q", "--latest-limit", "1", "--qf", "@%{name}\n%{requires}"}, pkgs...)...)
+	case config.DistroDEB:
+		cmd = exec.Command("apt-cache", append([]string{"show", "--no-all-versions"}, pkgs...)...)
+	default:
+		return "", fmt.Errorf("strict version check is not supported on %s", config.OSType)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to query package dependencies: %v", err)
+	}
+	return string(out), nil
+}
+
+// parseRequiredMinor parses the dependency dump of queryPackageRequires, and returns the minimal
+// postgres minor version each package requires, e.g. postgresql16-server >= 16.2 or postgresql-16 (>= 16.2)
+func parseRequiredMinor(output string, pgVer int) map[string]int {
+	re := regexp.MustCompile(fmt.Sprintf(`postgresql-?%d(?:-server)?\s*\(?>=\s*(?:\d+:)?%d\.(\d+)`, pgVer, pgVer))
+	result := make(map[string]int)
+	var name string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Package:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "Package:"))
+			continue
+		case strings.HasPrefix(line, "@"):
+			name = strings.TrimPrefix(line, "@")
+			continue
+		case name == "":
+			continue
+		case config.OSType == config.DistroDEB && !strings.HasPrefix(line, "Depends:") && !strings.HasPrefix(line, "Pre-Depends:"):
+			continue
+		}
+		for _, m := range re.FindAllStringSubmatch(line, -1) {
+			if minor, err := strconv.Atoi(m[1]); err == nil && minor > result[name] {
+				result[name] = minor
+			}
+		}
+	}
+	return result
+}
+
+// dropNewerMinor removes repo packages of available extensions that require a newer postgres
+// minor version than the installed one, so they are listed as norepo
+func dropNewerMinor(data []*Extension, pg *PostgresInstall, repo map[string]bool) {
+	pkgs := repoMainPackages(data, pg.MajorVersion, repo)
+	if len(pkgs) == 0 {
+		return
+	}
+	output, err := queryPackageRequires(pkgs)
+	if err != nil {
+		logrus.Warnf("skip minor version check: %v", err)
+		return
+	}
+	for name, minor := range parseRequiredMinor(output, pg.MajorVersion) {
+		if minor > pg.MinorVersion {
+			logrus.Debugf("package %s requires postgres %d.%d, installed %s", name, pg.MajorVersion, minor, pg.Version)
+			delete(repo, name)
+		}
+	}
+}
@@ -2,15 +2,19 @@ package ext
 
 import (
 	"fmt"
+	"os"
 	"pig/internal/config"
-	"pig/internal/utils"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/sirupsen/logrus"
 )
 
+// UpdateDatabases are databases to run ALTER EXTENSION UPDATE in after package update
+var UpdateDatabases []string
+
 // UpdateExtensions will upgrade extensions based on provided names, aliases, or categories
-func UpdateExtensions(pgVer int, names []string, yes bool) error {
+func UpdateExtensions(pgVer int, names []string, yes bool) (err error) {
 	logrus.Debugf("updating extensions: pgVer=%d, names=%s, yes=%v", pgVer, strings.Join(names, ", "), yes)
 	if len(names) == 0 {
 		return fmt.Errorf("no extension names provided")
@@ -19,58 +23,113 @@ func UpdateExtensions(pgVer int, names []string, yes bool) error {
 		logrus.Debugf("no PostgreSQL version specified, set target version to the latest major version: %d", PostgresLatestMajorVersion)
 		pgVer = PostgresLatestMajorVersion
 	}
+	report := newReport("update", pgVer, names)
+	defer func() { report.finish(err) }()
 
-	var updateCmds []string
+	backend, err := GetBackend()
+	if err != nil {
+		return err
+	}
 	Catalog.LoadAliasMap(config.OSType)
-	switch config.OSType {
-	case config.DistroEL:
-		updateCmds = append(updateCmds, []string{"yum", "update"}...)
-		if config.OSVersion == "8" || config.OSVersion == "9" {
-			updateCmds[0] = "dnf"
-		}
-		if yes {
-			updateCmds = append(updateCmds, "-y")
-		}
-	case config.DistroDEB:
-		updateCmds = append(updateCmds, []string{"apt-get", "upgrade"}...)
-		if yes {
-			updateCmds = append(updateCmds, "-y")
-		}
-	default:
-		return fmt.Errorf("unsupported OS type: %s", config.OSType)
+
+	pins, err := LoadPins()
+	if err != nil {
+		logrus.Warnf("failed to load extension pins: %v", err)
 	}
 
 	var pkgNames []string
+	var items []*ReportItem
 	for _, name := range names {
-		ext, ok := Catalog.ExtNameMap[name]
-		if !ok {
-			ext, ok = Catalog.ExtAliasMap[name]
-		}
+		ext, ok := lookupExtension(name)
 
 		if !ok {
 			// try to find in PostgresPackageMap (if it is not a postgres extension)
 			if pgPkg, ok := Catalog.AliasMap[name]; ok {
-				pkgNames = append(pkgNames, processPkgName(pgPkg, pgVer)...)
+				pkgNames = append(pkgNames, backend.Resolve(pgPkg, "", pgVer)...)
+				items = append(items, &ReportItem{Name: name, Packages: backend.Resolve(pgPkg, "", pgVer)})
 				continue
 			} else {
 				logrus.Debugf("cannot find '%s' in extension name or alias", name)
 				continue
 			}
 		}
+		if pinVer, pinned := pins[ext.Name]; pinned {
+			logrus.Warnf("extension %s is pinned to version %s, skip update", ext.Name, pinVer)
+			continue
+		}
 		pkgName := ext.PackageName(pgVer)
 		if pkgName == "" {
 			logrus.Warnf("no package found for extension %s", ext.Name)
 			continue
 		}
 		logrus.Debugf("translate extension %s to package name: %s", ext.Name, pkgName)
-		pkgNames = append(pkgNames, processPkgName(pkgName, pgVer)...)
+		pkgNames = append(pkgNames, backend.Resolve(pkgName, "", pgVer)...)
+		items = append(items, &ReportItem{Name: ext.Name, Version: ext.PgPackageVersion(pgVer), Packages: backend.Resolve(pkgName, "", pgVer)})
 	}
 
 	if len(pkgNames) == 0 {
 		return fmt.Errorf("no packages to be updated")
 	}
-	updateCmds = append(updateCmds, pkgNames...)
-	logrus.Infof("updating extensions: %s", strings.Join(updateCmds, " "))
+	logrus.Infof("updating extensions: %s", strings.Join(pkgNames, " "))
 
-	return utils.SudoCommand(updateCmds)
+	recordPrior(backend, items)
+	if err := backend.Update(pkgNames, yes); err != nil {
+		report.Failed = items
+		return err
+	}
+	report.Succeeded = items
+	return nil
+}
+
+// AlterExtensions runs ALTER EXTENSION UPDATE for given extensions in UpdateDatabases, and reports the result
+func AlterExtensions(names []string) error {
+	if len(UpdateDatabases) == 0 {
+		return fmt.Errorf("no database specified to update extensions in")
+	}
+	exts := resolveExtensions(names)
+	var failed []string
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Database\tExtension\tFrom\tTo\tResult")
+	fmt.Fprintln(w, "--------\t---------\t----\t--\t------")
+	for _, db := range UpdateDatabases {
+		created, err := QueryDatabaseExtensions(db)
+		if err != nil {
+			logrus.Errorf("failed to query extensions of database %s: %v", db, err)
+			failed = append(failed, db)
+			continue
+		}
+		createdMap := make(map[string]*DatabaseExtension, len(created))
+		for _, de := range created {
+			createdMap[de.Name] = de
+		}
+		for _, ext := range exts {
+			de, ok := createdMap[ext.Name]
+			if !ok {
+				logrus.Debugf("extension %s is not created in database %s, skip", ext.Name, db)
+				continue
+			}
+			result, target := "current", de.DefaultVersion
+			switch de.State() {
+			case StateBroken:
+				result, target = "skipped (files missing)", "-"
+			case StateUpdate:
+				sql := fmt.Sprintf("ALTER EXTENSION %s UPDATE;", quoteIdent(ext.Name))
+				logrus.Infof("%s -- in database %s", sql, db)
+				if _, err := PsqlQuery(db, sql); err != nil {
+					logrus.Errorf("failed to update extension %s in database %s: %v", ext.Name, db, err)
+					failed = append(failed, db+"."+ext.Name)
+					result = "failed"
+				} else {
+					result = "upgraded"
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", db, ext.Name, de.Version, target, result)
+		}
+	}
+	w.Flush()
+	fmt.Println()
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to update extensions in database: %s", strings.Join(failed, ", "))
+	}
+	return nil
 }
@@ -0,0 +1,50 @@
+package ext
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// UpgradeExtensions installs extensions installed on PostgreSQL `from` for PostgreSQL `to`
+// contrib extensions are skipped since they come with the server, unavailable ones are warned and skipped
+func UpgradeExtensions(d *Detection, from, to int, yes bool) error {
+	if from == 0 || to == 0 {
+		return fmt.Errorf("both --from and --to pg major versions are required")
+	}
+	if from == to {
+		return fmt.Errorf("source and target pg major versions are the same: %d", from)
+	}
+	pg := d.Major(from)
+	if pg == nil {
+		return fmt.Errorf("PostgreSQL %d installation not found", from)
+	}
+
+	var names, unavailable, unknown []string
+	for _, ei := range pg.Extensions {
+		if ei.Extension == nil {
+			unknown = append(unknown, ei.ExtName())
+			continue
+		}
+		if ei.Repo == "CONTRIB" {
+			continue
+		}
+		if !ei.Available(to) || ei.PackageName(to) == "" {
+			unavailable = append(unavailable, ei.Name)
+			continue
+		}
+		names = append(names, ei.Name)
+	}
+	if len(unknown) > 0 {
+		logrus.Warnf("not found in catalog, install them for PostgreSQL %d manually: %s", to, strings.Join(unknown, ", "))
+	}
+	if len(unavailable) > 0 {
+		logrus.Warnf("not available for PostgreSQL %d: %s", to, strings.Join(unavailable, ", "))
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no extensions of PostgreSQL %d to be installed for PostgreSQL %d", from, to)
+	}
+	logrus.Infof("installing %d extensions of PostgreSQL %d for PostgreSQL %d: %s", len(names), from, to, strings.Join(names, ", "))
+	return InstallExtensions(to, names, yes)
+}
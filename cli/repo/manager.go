@@ -4,7 +4,6 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
-	"path/filepath"
 	"pig/cli/get"
 	"pig/internal/config"
 	"slices"
@@ -73,7 +72,7 @@ func NewRepoManager(paths ...string) (rm *RepoManager, err error) {
 	var data []byte
 	var defaultCsvPath string
 	if config.ConfigDir != "" {
-		defaultCsvPath = filepath.Join(config.ConfigDir, "repo.yml")
+		defaultCsvPath = config.ConfigPath("repo.yml")
 		if !slices.Contains(paths, defaultCsvPath) {
 			paths = append(paths, defaultCsvPath)
 		}
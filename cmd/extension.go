@@ -4,19 +4,77 @@ Copyright © 2024 Ruohang Feng <rh@vonng.com>
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"pig/cli/ext"
+	"pig/internal/config"
+	"pig/internal/utils"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
-	extPgVer       int
-	extPgConfig    string
-	extShowContrib bool
-	extYes         bool
+	extPgVer          int
+	extPgVers         []int
+	extPgConfig       string
+	extShowContrib    bool
+	extYes            bool
+	extFormat         string
+	extOpen           string
+	extOutput         string
+	extJSONSchema     bool
+	extDiffDB         []string
+	extAssumePg       int
+	extNew            bool
+	extNewSince       string
+	extPgRoots        []string
+	extNoBox          bool
+	extExamples       bool
+	extMinimal        bool
+	extShowFiles      bool
+	extUpgradeFrom    int
+	extUpgradeTo      int
+	extCategory       []string
+	extRequire        []string
+	extLicense        []string
+	extHasPackage     string
+	extNoPackage      string
+	extInstalled      bool
+	extMirrorPg       int
+	extMirrorArch     string
+	extMirrorDir      string
+	extMirrorJobs     int
+	extMirrorIndex    bool
+	extOutputFile     string
+	extHistory        bool
+	extSince          string
+	extPrint          bool
+	extBundles        bool
+	extGroups         []string
+	extForceOS        string
+	extOSVersion      string
+	extInfoDeb        bool
+	extInfoRpm        bool
+	extStatusDB       string
+	extPrometheus     bool
+	extTextfileDir    string
+	extInfoAll        bool
+	extInfoAllMatches bool
+	extRelated        bool
+	extIgnoreMissing  bool
+	extUpdateInDB     bool
+	extKeepConfig     bool
+	extDetected       = &ext.Detection{} // detected PostgreSQL installations, filled by extProbeVersion
 )
 
 // extCmd represents the installation command
@@ -33,46 +91,67 @@ Description:
   pig ext remove  [ext...]     # remove extension for current pg version
   pig ext update  [ext...]     # update extension to the latest version
   pig ext status               # show installed extension and pg status
+  pig ext size                 # show disk usage of installed extensions
+  pig ext mirror               # download all catalog packages for offline repo
+  pig ext resolve [ext...]     # show canonical name and package names
+  pig ext pending-restart      # list extensions waiting for preload & restart
+  pig ext selftest             # print environment pig sees for bug report
+  pig ext upgrade-pg --from 15 --to 16   # install pg15 extensions for pg16
+  pig ext licenses             # count catalog extensions by license
+  pig ext catalog stats        # show catalog coverage metrics
+  pig ext cache   [info|clean] # manage local package cache
+  pig ext pin     [ext=ver...] # pin extension version, list pins if no args
+  pig ext unpin   [ext...]     # release extension version pins
+  pig ext config  [list|get|set]  # view and edit pig settings
+  pig ext validate-manifest <file> # check manifest entries against catalog
+  pig ext history [--since 7d] # show install, remove and update log
+  pig ext rollback [--to id]   # undo operations recorded in history
+  pig ext edit-preload add|remove [lib...]  # edit shared_preload_libraries
+  pig ext exists  [ext...]     # exit 0 if extensions exist, for scripting
 `,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := initAll(); err != nil {
+			return err
+		}
+		extApplySettings(cmd)
+		if len(extPgVers) > 1 && cmd != extAddCmd {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("multiple pg versions %v given, only install accepts a list of versions", extPgVers)
+		}
+		extLoadCatalog()
+		return nil
+	},
 }
 
 var extListCmd = &cobra.Command{
 	Use:     "list [query]",
 	Short:   "list & search available extensions",
-	Aliases: []string{"l", "ls", "find"},
+	Aliases: []string{"l", "ls", "find", "search"},
 	Example: `
   pig ext list                # list all extensions
   pig ext list postgis        # search extensions by name/description
   pig ext ls olap             # list extension of olap category
   pig ext ls gis -v 16        # list gis category for pg 16
+  pig ext ls --category gis,rag         # list extensions of given categories
+  pig ext ls --bundles                  # list available extension bundles
+  pig ext ls --require postgis          # list extensions that depend on postgis
+  pig ext ls --require postgis --installed-only   # only installed dependents
+  pig ext search cron --installed       # search installed extensions by partial name
+  pig ext search vector -o json         # search with relevance scores in json
+  pig ext ls --license MIT,PostgreSQL,Apache-2.0   # list extensions of given licenses
+  pig ext ls --has-package rpm          # list extensions with a rpm package
+  pig ext ls --no-package               # list source-only extensions on current os
+  pig ext ls --no-package=rpm           # list extensions without rpm package
+  pig ext ls -v 16 --strict-version     # mark extensions without a package for installed minor as norepo
+  pig ext ls --format '{{.Name}} {{.Version}} {{join .PgVer ","}}'
+  pig ext ls --new                      # list extensions added to catalog in last 30 days
+  pig ext ls --width 120 | less         # shrink description to fit 120 columns when piped
+  pig ext ls rag --wide-desc            # print full descriptions wrapped to multiple lines
+  pig ext ls rag --format wide          # same as --wide-desc, as status --format wide
+  pig ext ls --new-since 2024-12-01     # list extensions added to catalog since given date
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) > 1 {
-			logrus.Errorf("too many arguments, only one search query allowed")
-			os.Exit(1)
-		}
-
-		results := ext.Catalog.Extensions
-		if len(args) == 1 {
-			query := args[0]
-			results = ext.SearchExtensions(query, ext.Catalog.Extensions)
-			if len(results) == 0 {
-				logrus.Warnf("no extensions found matching '%s'", query)
-				return nil
-			} else {
-				logrus.Infof("found %d extensions matching '%s':", len(results), query)
-			}
-		}
-
-		pgVer := extProbeVersion()
-		if pgVer == 0 {
-			logrus.Debugf("no active PostgreSQL found, fallback to common tabulate")
-			ext.TabulteCommon(results)
-		} else {
-			ext.TabulteVersion(pgVer, results)
-		}
-		return nil
-
+		return extWithOutput(cmd, func() error { return extList(args) })
 	},
 }
 
@@ -80,20 +159,34 @@ var extInfoCmd = &cobra.Command{
 	Use:     "info",
 	Short:   "get extension information",
 	Aliases: []string{"i"},
+	Example: `
+  pig ext info postgis              # show postgis information
+  pig ext info postgis --open       # open postgis website in browser
+  pig ext info postgis --open=summary  # open postgis catalog page in browser
+  pig ext info postgis --open=source   # open postgis source repository in browser
+  pig ext info postgis -o json      # show postgis information in json
+  pig ext info postgis --no-box     # show postgis information as plain text
+  pig ext info pg_trgm --examples   # show example commands, and whether non-superuser can create it
+  pig ext info pg_cron --show-files # list files of pg_cron packages, grouped by libs, sql and docs
+  pig ext info postgis --width 100  # show postgis information in a 100 columns box
+  pig ext info postgis --history    # show available postgis versions across pg majors
+  pig ext info postgis --all        # dump every field and resolved package, for debugging catalog
+  pig ext info a b c --ignore-missing  # report unknown names at the end without failing
+  pig ext info vector --related     # show similar extensions to discover alternatives
+  pig ext info vec --all-matches    # show every extension matching a partial name
+  pig ext info postgis --deb        # print deb package metadata only (--rpm for rpm)
+  pig ext info --json-schema        # print json schema of extension json output
+  pig ext info postgis -o json --output-file postgis.json  # write output to file atomically
+`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pgVer := extProbeVersion()
-		logrus.Debugf("using PostgreSQL version: %d", pgVer)
-		for _, name := range args {
-			e, ok := ext.Catalog.ExtNameMap[name]
-			if !ok {
-				e, ok = ext.Catalog.ExtAliasMap[name]
-				if !ok {
-					logrus.Errorf("extension '%s' not found", name)
-					continue
-				}
-			}
-			e.PrintInfo()
+		var missing []string
+		if err := extWithOutput(cmd, func() (err error) {
+			missing, err = extInfo(args)
+			return err
+		}); err != nil {
+			return err
 		}
+		extReportMissing(missing)
 		return nil
 	},
 }
@@ -108,20 +201,99 @@ Description:
   pig ext install postgis timescaledb        # install multiple extensions
   pig ext add     pgvector pgvectorscale     # other alias: add, ins, i, a
   pig ext ins     pg_search -y               # auto confirm installation
-  pig ext install pgsql                      # install the latest version of postgresql kernel
+  pig ext install pgsql                      # install the latest postgresql kernel available in repos
   pig ext a pg17                             # install postgresql 17 kernel packages
   pig ext ins pg16                           # install postgresql 16 kernel packages
   pig ext install pg15-core                  # install postgresql 15 core packages
+  pig ext install pgrouting postgis --create --dependency-order  # create postgis before pgrouting
+  pig ext install pg17 --restart             # install kernel and restart postgres if recommended
   pig ext install pg14-main -y               # install pg 14 + essential extensions (vector, repack, wal2json)
   pig ext install pg13-devel --yes           # install pg 13 devel packages (auto-confirm)
   pig ext install pgsql-common               # install common utils such as patroni pgbouncer pgbackrest,...
+  pig ext install pg_cron -v 15,16           # install extension for multiple pg major versions
+  pig ext install --group gis-stack          # install a named bundle (see pig ext ls --bundles)
+  pig ext install vector --create -d app     # install and CREATE EXTENSION in database app
+  pig ext install vector --create --to-schema ext  # create relocatable extension in schema ext
+  pig ext install vector --create -d app --post-create-sql grant.sql  # run follow-up sql after create
+  pig ext install pg_cron --post-install-hook 'echo $PIG_INSTALLED_EXTS'  # run hook after install
+  pig ext install postgis pgvector --simulate-resolve  # print resolved package list only
+  pig ext install postgis pgvector -q          # install without time summary
+  pig ext install pg_cron --no-wait            # fail fast if another pig is running
+  pig ext install postgis --download-only-if-missing  # reuse cached packages, cache missing ones
+  pig ext install rag-stack --download-only-if-missing -j 8  # download packages with 8 jobs
+  pig ext install postgis --report /var/log/pig/install.json  # write json install report for audit
+  pig ext install postgis --repo-url https://example.com/repo --allow-unsigned  # install from a custom repo, for testing
+  pig ext install postgis --verbose            # show which key or signed repo each package comes from
+  pig ext install pg_partman -v 18 --prefer-version nearest  # fall back to nearest older pg if not available
+  pig ext install citus --enable-repo=pgdg-extras    # enable a disabled repo during this install
+  pig ext install postgis --disable-repo=epel        # disable a conflicting repo during this install
+  pig ext install postgis --log-file /tmp/pig-install.log  # also append full apt/dnf output to a log file
+  pig ext install timescaledb --show-preload-diff  # print shared_preload_libraries change, do not edit it
+  pig ext install timescaledb --edit-preload       # add timescaledb to shared_preload_libraries
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		args = append(args, extGroups...)
 		pgVer := extProbeVersion()
-		if err := ext.InstallExtensions(pgVer, args, extYes); err != nil {
+		if !ext.SimulateResolve {
+			extGuardForcedOS()
+		}
+		if err := ext.CheckTargetSchema(args); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		if err := ext.CheckPostCreateSQL(); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		if err := ext.CheckRepoURL(ext.RepoURL); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		if err := ext.CheckSignatureOptions(); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		if err := ext.CheckPreferVersion(ext.PreferVersion); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		ext.JobsGiven = cmd.Flags().Changed("jobs")
+		if ext.JobsGiven {
+			if err := ext.CheckJobs(); err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+		}
+		ext.PgVersionGiven = len(extPgVers) > 0 || extPgConfig != ""
+		defer extLock()()
+		closeLog, err := ext.OpenLogFile()
+		if err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		defer closeLog()
+		if len(extPgVers) > 1 {
+			err = ext.InstallExtensionsMulti(extPgVers, args, extYes)
+		} else {
+			err = ext.InstallExtensions(pgVer, args, extYes)
+		}
+		extWriteReport()
+		if err != nil {
+			extExitInterrupted(err)
 			logrus.Errorf("failed to install extensions: %v", err)
 			return nil
 		}
+		if ext.SimulateResolve {
+			return nil
+		}
+		if err := ext.CreateExtensions(args); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		if err := ext.RunPostInstallHook(args); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
 		return nil
 	},
 }
@@ -131,8 +303,15 @@ var extRmCmd = &cobra.Command{
 	Short:   "remove postgres extension",
 	Aliases: []string{"r", "remove"},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("keep-config") {
+			ext.Purge = !extKeepConfig
+		}
 		pgVer := extProbeVersion()
+		extGuardForcedOS()
+		defer extLock()()
+		defer extWriteReport()
 		if err := ext.RemoveExtensions(pgVer, args, extYes); err != nil {
+			extExitInterrupted(err)
 			logrus.Errorf("failed to remove extensions: %v", err)
 			return nil
 		}
@@ -150,13 +329,28 @@ Description:
   pig ext update postgis             # update specific extension
   pig ext update postgis timescaledb # update multiple extensions
   pig ext up pg_vector -y            # update with auto-confirm
+  pig ext update pg_cron --in-db -d app,meta  # then run ALTER EXTENSION UPDATE in databases
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if extUpdateInDB && len(ext.UpdateDatabases) == 0 {
+			logrus.Errorf("--in-db requires --dbname to specify databases")
+			os.Exit(1)
+		}
 		pgVer := extProbeVersion()
+		extGuardForcedOS()
+		defer extLock()()
+		defer extWriteReport()
 		if err := ext.UpdateExtensions(pgVer, args, extYes); err != nil {
+			extExitInterrupted(err)
 			logrus.Errorf("failed to update extensions: %v", err)
 			return nil
 		}
+		if extUpdateInDB {
+			if err := ext.AlterExtensions(args); err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+		}
 		return nil
 	},
 }
@@ -165,9 +359,56 @@ var extStatusCmd = &cobra.Command{
 	Use:     "status",
 	Short:   "show installed extension on active pg",
 	Aliases: []string{"s", "st", "stat"},
+	Example: `
+  pig ext status                     # show installed extensions on active pg
+  pig ext status -c                  # show contrib extensions too
+  pig ext status --format wide       # add load, superuser, schema, relocatable columns
+  pig ext status --format '{{.Name}} {{.Version}}'  # print installed extensions with a go template
+  pig ext status --diff-db stg,prod  # compare enabled extensions of two databases
+  pig ext status -d app              # show extensions created in database app, and pending updates
+  pig ext status --age               # show how long ago each extension was installed
+  pig ext status --age -o json       # show installed extensions in json with install timestamps
+  pig ext status --prometheus        # print extension metrics in prometheus text format
+  pig ext status --prometheus --textfile-dir /var/lib/node_exporter  # write metrics for textfile collector
+`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		extProbeVersion()
-		ext.ExtensionStatus(extShowContrib)
+		if extPrometheus || extTextfileDir != "" {
+			if err := extWritePrometheus(extTextfileDir); err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			return nil
+		}
+		if len(extDiffDB) > 0 {
+			if err := ext.DiffDatabaseExtensions(extDiffDB); err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			return nil
+		}
+		if extStatusDB != "" {
+			if err := ext.DatabaseStatus(extStatusDB); err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			return nil
+		}
+		if extFormat != "" && extFormat != "wide" {
+			if err := ext.ExtensionStatusTemplate(extShowContrib, extFormat); err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			return nil
+		}
+		if extOutput == "json" {
+			if err := ext.ExtensionStatusJSON(extShowContrib); err != nil {
+				logrus.Error(err)
+				os.Exit(1)
+			}
+			return nil
+		}
+		ext.ExtensionStatus(extDetected, extShowContrib, extFormat == "wide")
 		return nil
 	},
 }
@@ -188,53 +429,1055 @@ var extScanCmd = &cobra.Command{
 	},
 }
 
+var extMirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "download all catalog packages into a local repo dir",
+	Example: `
+  pig ext mirror --pg 16 -d ./mirror            # download all extension packages for pg 16
+  pig ext mirror --pg 17 --arch aarch64 -j 8    # download arm packages with 8 concurrent jobs
+  pig ext mirror --pg 16 -d /www/pgext --index  # download and run createrepo / dpkg-scanpackages
+  pig ext mirror --pg 16 --force-os rhel --os-version 9  # stage el9 packages list on another host
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extApplyForceOS()
+		if extMirrorPg == 0 {
+			extMirrorPg = extProbeVersion()
+		}
+		if err := ext.MirrorExtensions(extMirrorPg, extMirrorArch, extMirrorDir, extMirrorJobs, extMirrorIndex); err != nil {
+			logrus.Errorf("failed to mirror extensions: %v", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "manage local package cache",
+	Example: `
+  pig ext cache info                 # show package cache dir and usage
+  pig ext cache clean                # remove all cached packages
+`,
+}
+
+var extCacheInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "show package cache dir and usage",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extCacheDir()
+		if err := ext.CacheInfo(); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extCacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "remove all cached packages",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer extLock()()
+		extCacheDir()
+		if err := ext.CacheClean(); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extCatalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "inspect the extension catalog",
+	Example: `
+  pig ext catalog stats              # show catalog coverage by category, license, pg version & package
+  pig ext catalog stats -o json      # print catalog stats in json
+`,
+}
+
+var extCatalogStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "show catalog coverage metrics",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ext.Catalog.PrintCatalogStats(extOutput); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "resolve extension to canonical name and package names",
+	Example: `
+  pig ext resolve postgis            # show canonical name and packages for active pg
+  pig ext resolve pgvector -v 16     # resolve alias pgvector for pg 16
+  pig ext resolve postgis -o json    # print resolution in json for scripting
+  pig ext resolve postgis --force-os rhel --os-version 8  # resolve for el8 hosts
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			logrus.Errorf("no extension name provided")
+			os.Exit(1)
+		}
+		pgVer := extProbeVersion()
+		if err := ext.PrintResolutions(args, pgVer, extOutput); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extValidateManifestCmd = &cobra.Command{
+	Use:   "validate-manifest <file>",
+	Short: "check extension manifest against catalog and pg",
+	Args:  cobra.ExactArgs(1),
+	Example: `
+  pig ext validate-manifest manifest.yml        # check names, versions and pg support of each entry
+  pig ext validate-manifest manifest.yml -v 17  # check against pg 17 if manifest has no pg_versions
+
+  manifest format:
+    pg_versions: [16, 17]     # optional, detected or latest pg if empty
+    extensions:
+      - postgis
+      - pg_cron=1.6.4         # pin a version available in catalog or repos
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pgVer := extProbeVersion()
+		if err := ext.ValidateManifest(args[0], pgVer); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extUpgradePgCmd = &cobra.Command{
+	Use:   "upgrade-pg",
+	Short: "install extensions of one pg major version for another",
+	Example: `
+  pig ext upgrade-pg --from 15 --to 16      # install pg15 extensions for pg16
+  pig ext upgrade-pg --from 15 --to 17 -y   # auto confirm install
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extProbeVersion()
+		extGuardForcedOS()
+		defer extLock()()
+		err := ext.UpgradeExtensions(extDetected, extUpgradeFrom, extUpgradeTo, extYes)
+		extWriteReport()
+		if err != nil {
+			extExitInterrupted(err)
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extPendingRestartCmd = &cobra.Command{
+	Use:   "pending-restart",
+	Short: "list installed extensions not loaded by shared_preload_libraries yet",
+	Example: `
+  pig ext pending-restart            # list extensions need preload & restart on active pg
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extProbeVersion()
+		if err := ext.PrintPendingRestart(); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extSelfTestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "print environment pig sees for bug report",
+	Example: `
+  pig ext selftest                   # print os, package manager, postgres, catalog and repos
+  pig ext selftest -o json           # print the report in json
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extProbeVersion()
+		if err := ext.PrintSelfTest(extOutput); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extLicensesCmd = &cobra.Command{
+	Use:     "licenses",
+	Short:   "count catalog extensions by license",
+	Aliases: []string{"license"},
+	Example: `
+  pig ext licenses                   # show extension count and copyleft kind of each license
+  pig ext ls --license GPL-3.0       # then list extensions of a specific license
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ext.Catalog.PrintLicenses()
+		return nil
+	},
+}
+
+var extSizeCmd = &cobra.Command{
+	Use:   "size",
+	Short: "show disk usage of installed extensions",
+	Example: `
+  pig ext size                       # show disk usage of extensions on active pg
+  pig ext size -v 16 -o json         # show disk usage of extensions on pg 16 in json
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extProbeVersion()
+		if err := ext.PrintExtensionSizes(extOutput); err != nil {
+			logrus.Errorf("failed to get extension sizes: %v", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "show install, remove and update operations performed by pig",
+	Example: `
+  pig ext history                    # show all recorded operations
+  pig ext history --since 7d         # show operations of the last 7 days
+  pig ext history --since 2025-01-01 -o json  # print operations since a date in json
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, err := ext.ParseSince(extSince, time.Now())
+		if err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		if err := ext.PrintOperationHistory(since, extOutput); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "undo install, remove or update recorded in history",
+	Example: `
+  pig ext rollback                   # undo the latest install, remove or update
+  pig ext rollback --to 12           # undo history entry #12 and all operations after it
+  pig ext history                    # find the entry id to roll back to
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extApplyForceOS()
+		extGuardForcedOS()
+		defer extLock()()
+		defer extWriteReport()
+		if err := ext.Rollback(extYes); err != nil {
+			extExitInterrupted(err)
+			logrus.Errorf("failed to roll back: %v", err)
+			cmd.SilenceUsage, cmd.SilenceErrors = true, true
+			return err // returned instead of exit, so the deferred report is written
+		}
+		return nil
+	},
+}
+
+var extEditPreloadCmd = &cobra.Command{
+	Use:   "edit-preload [add|remove] [lib...]",
+	Short: "add or remove libraries in shared_preload_libraries",
+	Example: `
+  pig ext edit-preload                       # show current shared_preload_libraries
+  pig ext edit-preload add timescaledb       # append timescaledb, existing entries are kept
+  pig ext edit-preload remove pg_cron        # remove pg_cron, keep the order of others
+  pig ext edit-preload add citus --conf /pg/data/postgresql.conf  # edit the file instead of ALTER SYSTEM
+
+  ALTER SYSTEM is used if PostgreSQL is running, otherwise postgresql.conf in PGDATA is edited.
+  A restart is required for the change to take effect.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extProbeVersion()
+		var err error
+		if len(args) == 0 {
+			err = ext.PrintPreloadLibraries()
+		} else {
+			err = ext.EditPreloadLibraries(args[0], args[1:])
+		}
+		if err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extExistsCmd = &cobra.Command{
+	Use:   "exists <ext...>",
+	Short: "check whether extensions exist in catalog, for scripting",
+	Args:  cobra.MinimumNArgs(1),
+	Example: `
+  pig ext exists postgis                 # exit 0 if postgis is in catalog, 1 otherwise
+  pig ext exists pgvector --print        # print canonical name: vector
+  pig ext exists pg_cron --installed     # exit 0 only if installed on active pg
+  if pig ext exists timescaledb; then pig ext add timescaledb; fi
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if extInstalled {
+			extProbeVersion()
+		}
+		found := true
+		for _, name := range args {
+			canonical, ok := ext.ExtensionExists(name, extInstalled)
+			if !ok {
+				found = false
+				continue
+			}
+			if extPrint {
+				fmt.Println(canonical)
+			}
+		}
+		if !found {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var extPinCmd = &cobra.Command{
+	Use:   "pin",
+	Short: "pin extension version",
+	Example: `
+  pig ext pin                        # list current extension pins
+  pig ext pin postgis=3.4.2          # pin postgis to version 3.4.2
+  pig ext pin pg_cron                # pin pg_cron to installed (or catalog) version
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return ext.ListPins()
+		}
+		extProbeVersion()
+		if err := ext.PinExtensions(args); err != nil {
+			logrus.Errorf("failed to pin extensions: %v", err)
+			return nil
+		}
+		return nil
+	},
+}
+
+var extUnpinCmd = &cobra.Command{
+	Use:   "unpin",
+	Short: "release extension version pin",
+	Example: `
+  pig ext unpin postgis              # release the version pin of postgis
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			logrus.Errorf("no extension names provided")
+			return nil
+		}
+		if err := ext.UnpinExtensions(args); err != nil {
+			logrus.Errorf("failed to unpin extensions: %v", err)
+			return nil
+		}
+		return nil
+	},
+}
+
+var extConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "view and edit pig settings in config file",
+	Example: `
+  pig ext config list                # list known settings and current values
+  pig ext config get pg_version      # print the value of a setting
+  pig ext config set pg_version 16   # use PostgreSQL 16 by default when -v is not given
+  pig ext config set pg_roots /opt/pg%s,/usr/local/pg%s  # set a list value, comma separated
+  pig ext config set proxy http://10.10.10.1:3128        # proxy for downloads and package managers
+`,
+}
+
+var extConfigListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list known settings and current values",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Key\tValue\tType\tDescription")
+		fmt.Fprintln(w, "---\t-----\t----\t-----------")
+		for _, s := range config.Settings {
+			value, _ := config.GetSetting(s.Key)
+			if value == "" {
+				value = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Key, value, s.Type, s.Desc)
+		}
+		w.Flush()
+		fmt.Printf("\n(%d Rows) (Config: %s)\n\n", len(config.Settings), config.ConfigFile)
+		return nil
+	},
+}
+
+var extConfigGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "print the value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, err := config.GetSetting(args[0])
+		if err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var extConfigSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "write a setting into config file",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.SetSetting(args[0], args[1]); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		logrus.Infof("set %s = %s in %s", args[0], args[1], config.ConfigFile)
+		return nil
+	},
+}
+
+// extApplySettings applies settings from config file as defaults of flags not given on command line
+func extApplySettings(cmd *cobra.Command) {
+	if f := cmd.Flags().Lookup("version"); f != nil && !f.Changed && extPgConfig == "" && extAssumePg == 0 {
+		if v := viper.GetInt("pg_version"); v > 0 {
+			logrus.Debugf("use PostgreSQL %d from config file", v)
+			extPgVers = []int{v}
+		}
+	}
+	if f := cmd.Flags().Lookup("yes"); f != nil && !f.Changed && viper.GetBool("yes") {
+		extYes = true
+	}
+	if f := cmd.Flags().Lookup("exclude-recommends"); f != nil && !f.Changed && viper.GetBool("exclude_recommends") {
+		ext.ExcludeRecommends = true
+	}
+	if f := cmd.Flags().Lookup("output"); f != nil && !f.Changed && viper.GetString("output") != "" {
+		extOutput = viper.GetString("output")
+	}
+	if proxy := viper.GetString("proxy"); proxy != "" {
+		for _, env := range []string{"http_proxy", "https_proxy", "HTTP_PROXY", "HTTPS_PROXY"} {
+			if os.Getenv(env) == "" {
+				os.Setenv(env, proxy)
+			}
+		}
+	}
+}
+
+// extLoadCatalog replaces the embedded catalog with pigsty.csv in config dir (if exists) for every ext subcommand
+func extLoadCatalog() {
+	catalog, err := ext.LoadUserCatalog()
+	if err != nil {
+		logrus.Warnf("%v, use the embedded catalog", err)
+	} else if catalog != nil {
+		logrus.Debugf("use extension catalog %s", catalog.DataPath)
+		ext.SetCatalog(catalog)
+	}
+}
+
+// extReportMissing reports extensions not found, and exits non-zero unless --ignore-missing is given
+func extReportMissing(missing []string) {
+	if len(missing) == 0 {
+		return
+	}
+	if extIgnoreMissing {
+		logrus.Warnf("%d extensions not found: %s", len(missing), strings.Join(missing, ", "))
+		return
+	}
+	logrus.Errorf("%d extensions not found: %s", len(missing), strings.Join(missing, ", "))
+	extExit(1)
+}
+
+// extWritePrometheus writes extension metrics to stdout, or atomically into the textfile dir if given
+func extWritePrometheus(dir string) error {
+	if dir == "" {
+		return ext.WritePrometheus(os.Stdout)
+	}
+	commit, err := utils.StdoutToFile(filepath.Join(dir, ext.PrometheusTextfile))
+	if err != nil {
+		return err
+	}
+	if err := ext.WritePrometheus(os.Stdout); err != nil {
+		_ = commit(false) // keep the last complete metrics instead of a partial file
+		return err
+	}
+	return commit(true)
+}
+
+// extList lists & searches catalog extensions, the body of ext list
+func extList(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments, only one search query allowed")
+	}
+	if extBundles {
+		ext.PrintBundles()
+		return nil
+	}
+
+	results := ext.Catalog.Extensions
+	if extInstalled {
+		extProbeVersion()
+		if ext.Postgres == nil {
+			return fmt.Errorf("no PostgreSQL found to check installed extensions")
+		}
+		results = ext.FilterInstalled(results, ext.Postgres)
+		logrus.Debugf("%d extensions installed on PostgreSQL %d", len(results), ext.Postgres.MajorVersion)
+	}
+	var ranked []ext.ScoredExtension
+	if len(args) == 1 {
+		query := args[0]
+		if extOutput == "json" {
+			ranked = ext.SearchExtensionsRanked(query, results)
+			results = make([]*ext.Extension, len(ranked))
+			for i, r := range ranked {
+				results[i] = r.Extension
+			}
+		} else {
+			results = ext.SearchExtensions(query, results)
+			ext.Highlight = query
+		}
+		if len(results) == 0 {
+			logrus.Warnf("no extensions found matching '%s'", query)
+			return nil
+		} else {
+			logrus.Infof("found %d extensions matching '%s':", len(results), query)
+		}
+	}
+
+	if len(extCategory) > 0 {
+		results = ext.FilterCategory(results, extCategory)
+		logrus.Debugf("%d extensions in category %s", len(results), strings.Join(extCategory, ", "))
+	}
+	if len(extRequire) > 0 {
+		results = ext.FilterRequire(results, extRequire)
+		logrus.Infof("found %d extensions require %s", len(results), strings.Join(extRequire, ", "))
+	}
+	if len(extLicense) > 0 {
+		results = ext.FilterLicense(results, extLicense)
+		logrus.Debugf("%d extensions with license %s", len(results), strings.Join(extLicense, ", "))
+	}
+	if extHasPackage != "" || extNoPackage != "" {
+		pkgType, has := extHasPackage, true
+		if extNoPackage != "" {
+			pkgType, has = extNoPackage, false
+		}
+		var err error
+		if results, err = ext.FilterPackage(results, pkgType, has); err != nil {
+			return err
+		}
+		logrus.Debugf("%d extensions with package filter %s=%v", len(results), pkgType, has)
+	}
+
+	// record first seen date of catalog extensions, so new ones can be listed later
+	seen, err := ext.Catalog.FirstSeen()
+	if err != nil {
+		logrus.Debugf("failed to record catalog seen date: %v", err)
+	}
+	if extNew || extNewSince != "" {
+		since := time.Now().AddDate(0, 0, -30)
+		if extNewSince != "" {
+			t, err := time.Parse(ext.SeenDateFormat, extNewSince)
+			if err != nil {
+				return fmt.Errorf("invalid date %q, should be YYYY-MM-DD: %v", extNewSince, err)
+			}
+			since = t
+		}
+		if seen == nil {
+			return fmt.Errorf("failed to find new extensions: %v", err)
+		}
+		newExts := ext.NewExtensions(results, seen, since)
+		logrus.Infof("found %d new extensions since %s", len(newExts), since.Format(ext.SeenDateFormat))
+		results = newExts
+	}
+
+	if extOutput == "json" {
+		return ext.PrintSearchJSON(ranked, results)
+	}
+	if extFormat == "wide" {
+		ext.WideDesc = true
+	} else if extFormat != "" {
+		return ext.TabulteTemplate(extFormat, results)
+	}
+
+	pgVer := extProbeVersion()
+	if pgVer == 0 {
+		logrus.Debugf("no active PostgreSQL found, fallback to common tabulate")
+		ext.TabulteCommon(results)
+	} else {
+		ext.TabulteVersion(pgVer, results)
+	}
+	return nil
+}
+
+// extInfo prints information of given extensions, and returns names not found, the body of ext info
+func extInfo(args []string) ([]string, error) {
+	var missing []string
+	if err := ext.CheckInfoWidth(ext.InfoWidth); err != nil {
+		return nil, err
+	}
+	if extJSONSchema {
+		return nil, utils.PrintJSON(ext.ExtensionJSONSchema())
+	}
+	if extOpen != "" {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("no extension name provided to open")
+		}
+		e, ok := ext.Catalog.ExtNameMap[args[0]]
+		if !ok {
+			e, ok = ext.Catalog.ExtAliasMap[args[0]]
+		}
+		if !ok {
+			return nil, fmt.Errorf("extension '%s' not found", args[0])
+		}
+		url, err := e.LinkURL(extOpen)
+		if err != nil {
+			return nil, err
+		}
+		if err := utils.OpenBrowser(url); err != nil {
+			logrus.Warnf("failed to open browser: %v", err)
+			fmt.Println(url)
+		}
+		return nil, nil
+	}
+	pgVer := extProbeVersion()
+	logrus.Debugf("using PostgreSQL version: %d", pgVer)
+	var found []*ext.Extension
+	var pkgInfos []*ext.PackageInfo
+	var printed bool
+	var targets []*ext.Extension
+	for _, name := range args {
+		candidates := ext.InfoCandidates(name)
+		switch {
+		case len(candidates) == 0:
+			logrus.Debugf("extension '%s' not found", name)
+			missing = append(missing, name)
+			continue
+		case len(candidates) > 1 && !extInfoAllMatches:
+			logrus.Warnf("'%s' is ambiguous, matches %d extensions, please be specific or use --all-matches:", name, len(candidates))
+			for _, c := range candidates {
+				fmt.Fprintf(os.Stderr, "  %-24s %s\n", c.Name, c.EnDesc)
+			}
+			missing = append(missing, name+" (ambiguous)")
+			continue
+		case candidates[0].Name != name && candidates[0].Alias != name:
+			var names []string
+			for _, c := range candidates {
+				names = append(names, c.Name)
+			}
+			logrus.Infof("extension '%s' not found, showing %s", name, strings.Join(names, ", "))
+		}
+		targets = append(targets, candidates...)
+	}
+	for _, e := range targets {
+		if extInfoDeb || extInfoRpm {
+			var pkgTypes []string
+			if extInfoRpm {
+				pkgTypes = append(pkgTypes, "rpm")
+			}
+			if extInfoDeb {
+				pkgTypes = append(pkgTypes, "deb")
+			}
+			for _, pkgType := range pkgTypes {
+				if extOutput == "json" {
+					pkgInfos = append(pkgInfos, e.PackageInfo(pkgType))
+					continue
+				}
+				if printed {
+					fmt.Println()
+				}
+				e.PackageInfo(pkgType).Print()
+				printed = true
+			}
+			continue
+		}
+		if extOutput == "json" {
+			found = append(found, e)
+			continue
+		}
+		if extHistory {
+			e.PrintHistory()
+			continue
+		}
+		if extInfoAll {
+			if printed {
+				fmt.Println()
+			}
+			e.PrintAll(pgVer)
+			printed = true
+			continue
+		}
+		if extShowFiles {
+			for _, pf := range e.PackageFiles(pgVer) {
+				pf.Print()
+			}
+			continue
+		}
+		if extExamples {
+			fmt.Printf("%s\n\n", strings.Join(e.Examples(), "\n"))
+			continue
+		}
+		if extMinimal {
+			fmt.Println(e.MinimalInfo())
+			continue
+		}
+		if extNoBox {
+			e.PrintInfoPlain()
+		} else {
+			e.PrintInfo()
+		}
+		if extRelated {
+			e.PrintRelated()
+		}
+	}
+	if extOutput == "json" && (extInfoDeb || extInfoRpm) {
+		return missing, utils.PrintJSON(pkgInfos)
+	}
+	if extOutput == "json" {
+		return missing, utils.PrintJSON(found)
+	}
+	return missing, nil
+}
+
+// extOnExit holds cleanups that extExit runs before exiting, e.g. removing an uncommitted output file
+var extOnExit []func()
+
+// extExit runs pending cleanups and exits with given code, for helpers that exit in the middle of a command
+func extExit(code int) {
+	for _, cleanup := range extOnExit {
+		cleanup()
+	}
+	os.Exit(code)
+}
+
+// extWithOutput runs the command body with stdout redirected to --output-file if given, the file is written
+// only if the body succeeds, otherwise (error, early exit or interrupt) the temp file is removed
+func extWithOutput(cmd *cobra.Command, run func() error) error {
+	var commit func(keep bool) error
+	if extOutputFile != "" {
+		var err error
+		if commit, err = utils.StdoutToFile(extOutputFile); err != nil {
+			logrus.Error(err)
+			os.Exit(1)
+		}
+		extOnExit = append(extOnExit, func() { _ = commit(false) })
+	}
+	err := run()
+	if commit != nil {
+		extOnExit = nil
+		if commitErr := commit(err == nil); err == nil {
+			err = commitErr
+		}
+	}
+	if err != nil {
+		logrus.Error(err)
+		cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	}
+	return err
+}
+
+// extCacheDir applies package cache dir from config file if not specified by flag
+func extCacheDir() {
+	if ext.CacheDir == "" {
+		ext.CacheDir = viper.GetString("cache_dir")
+	}
+}
+
+// extApplyForceOS overrides detected OS for package resolution if --force-os or --os-version is given
+func extApplyForceOS() {
+	if (extForceOS == "" && extOSVersion == "") || config.OSForced {
+		return
+	}
+	if err := config.ForceOS(extForceOS, extOSVersion); err != nil {
+		logrus.Error(err)
+		extExit(1)
+	}
+	logrus.Infof("resolve packages for %s (%s) instead of %s", config.OSCode, config.OSType, config.RealOSCode)
+}
+
+// extGuardForcedOS refuses to modify packages when OS is forced to another distribution
+func extGuardForcedOS() {
+	if config.ForcedOSMismatch() {
+		logrus.Errorf("OS is forced to %s but running on %s, refuse to modify packages", config.OSCode, config.RealOSCode)
+		os.Exit(1)
+	}
+}
+
+// extLock acquires the pig lock before modifying packages, and returns the release function
+func extLock() func() {
+	release, err := ext.AcquireLock()
+	if err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
+	return release
+}
+
+// extCompleteCatalog completes extension names from the whole catalog
+func extCompleteCatalog(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, e := range ext.Catalog.Extensions {
+		if strings.HasPrefix(e.Name, toComplete) && !slices.Contains(args, e.Name) {
+			names = append(names, e.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// extCompleteInstalled completes extension names installed on the target postgres
+func extCompleteInstalled(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	pg := extCompletePostgres()
+	if pg == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, ei := range pg.Extensions {
+		name := ei.ExtName()
+		if strings.HasPrefix(name, toComplete) && !slices.Contains(args, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// extCompletePostgres finds the target postgres for shell completion, unlike extProbeVersion
+// it never logs or exits, so nothing but candidates reaches the shell
+func extCompletePostgres() *ext.PostgresInstall {
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.PanicLevel)
+	defer logrus.SetLevel(level)
+	ext.AddSearchRoots(extPgRoots...)
+	ext.AddSearchRoots(viper.GetStringSlice("pg_roots")...)
+	detected, err := ext.NewDetection()
+	if err != nil {
+		return nil
+	}
+	var pg *ext.PostgresInstall
+	switch {
+	case extPgConfig != "":
+		pg, _ = detected.Select(extPgConfig)
+	case len(extPgVers) > 0:
+		pg, _ = detected.Select(strconv.Itoa(extPgVers[0]))
+	default:
+		pg = detected.Active
+	}
+	return pg
+}
+
+// extWriteReport writes the json change report if --report is given
+func extWriteReport() {
+	if err := ext.WriteReport(); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// extExitInterrupted exits with 130 if the package manager was interrupted, after writing the report
+func extExitInterrupted(err error) {
+	if errors.Is(err, utils.ErrInterrupted) {
+		extWriteReport()
+		logrus.Errorf("aborted: %v", err)
+		os.Exit(130)
+	}
+}
+
 // extProbeVersion returns the PostgreSQL version to use
 func extProbeVersion() int {
-	ext.DetectPostgres()
+	extApplyForceOS()
+	// if pg version is assumed, skip detection entirely, for catalog / resolution purpose only
+	if extAssumePg != 0 {
+		if len(extPgVers) > 0 || extPgConfig != "" {
+			logrus.Errorf("--assume-pg can not be used with pg version or pg_config path")
+			extExit(1)
+		}
+		logrus.Debugf("assume PostgreSQL %d without detection", extAssumePg)
+		return extAssumePg
+	}
+	ext.AddSearchRoots(extPgRoots...)
+	ext.AddSearchRoots(viper.GetStringSlice("pg_roots")...)
+	extCacheDir()
+	if detected, err := ext.NewDetection(); err != nil {
+		logrus.Debugf("failed to detect PostgreSQL: %v", err)
+	} else {
+		extDetected = detected
+	}
+	for _, v := range extPgVers {
+		if err := ext.Catalog.CheckPgMajor(v); err != nil {
+			logrus.Warn(err)
+		}
+	}
+	if len(extPgVers) > 0 {
+		extPgVer = extPgVers[0]
+		if len(extPgVers) > 1 {
+			logrus.Debugf("multiple pg versions given: %v, install for each, %d is used for detection", extPgVers, extPgVer)
+		}
+	}
 	if extPgVer != 0 && extPgConfig != "" {
 		logrus.Errorf("both pg version and pg_config path are specified, please specify only one")
-		os.Exit(1)
+		extExit(1)
 	}
 
 	// if pg version is specified, try if we can find the actual installation
 	if extPgVer != 0 {
-		_, err := ext.GetPostgres(strconv.Itoa(extPgVer))
+		pg, err := extDetected.Select(strconv.Itoa(extPgVer))
 		if err != nil {
 			logrus.Debugf("PostgreSQL installation %d not found: %v , but it's ok", extPgVer, err)
 			// if version is explicitly given, we can fallback without any installation
 		}
+		ext.Postgres = pg
 		return extPgVer
 	}
 
 	// if pg_config is specified, we must find the actual installation, to get the major version
 	if extPgConfig != "" {
-		_, err := ext.GetPostgres(extPgConfig)
+		pg, err := extDetected.Select(extPgConfig)
 		if err != nil {
 			logrus.Errorf("failed to get PostgreSQL by pg_config path %s: %v", extPgConfig, err)
-			os.Exit(3)
+			extExit(3)
 		} else {
-			return ext.Postgres.MajorVersion
+			ext.Postgres = pg
+			return pg.MajorVersion
 		}
 	}
 
 	// if none given, we can fallback to active installation, or if we can't infer the version, we can fallback to no version tabulate
-	if ext.Active != nil {
-		logrus.Debugf("fallback to active PostgreSQL: %d", ext.Active.MajorVersion)
-		ext.Postgres = ext.Active
-		return ext.Active.MajorVersion
+	ext.WarnMixedPathVersions(extDetected.Active)
+	if active := extDetected.Active; active != nil {
+		logrus.Debugf("fallback to active PostgreSQL: %d", active.MajorVersion)
+		ext.Postgres = active
+		return active.MajorVersion
 	} else {
 		logrus.Debugf("no active PostgreSQL found, but it's ok")
 		return 0
 	}
 }
 
+// extFormatUsage is the --format help shared by list and status, which accept the same values
+const extFormatUsage = "output format: wide (more columns / full descriptions), or a go template applied to each extension"
+
 func init() {
-	extCmd.PersistentFlags().IntVarP(&extPgVer, "version", "v", 0, "specify a postgres by major version")
+	extCmd.PersistentFlags().IntSliceVarP(&extPgVers, "version", "v", nil, "specify a postgres by major version (install accepts a list: 15,16)")
 	extCmd.PersistentFlags().StringVarP(&extPgConfig, "path", "p", "", "specify a postgres by pg_config path")
+	extCmd.PersistentFlags().StringArrayVar(&extPgRoots, "pg-root", nil, "extra postgres search root (contains bin/pg_config), %s for major version")
+	extCmd.PersistentFlags().StringVar(&extForceOS, "force-os", "", "resolve packages for another os family: debian, ubuntu, rhel")
+	extCmd.PersistentFlags().StringVar(&extOSVersion, "os-version", "", "resolve packages for another os major version, used with --force-os")
+	extCmd.PersistentFlags().IntVar(&extAssumePg, "assume-pg", 0, "assume a postgres major version without detection")
+	extListCmd.Flags().StringVar(&extFormat, "format", "", extFormatUsage)
+	extListCmd.Flags().BoolVar(&extNew, "new", false, "list extensions added to catalog in last 30 days")
+	extListCmd.Flags().StringVar(&extNewSince, "new-since", "", "list extensions added to catalog since date (YYYY-MM-DD)")
+	extListCmd.Flags().BoolVar(&extBundles, "bundles", false, "list available extension bundles")
+	extListCmd.Flags().StringSliceVar(&extCategory, "category", nil, "filter extensions by category: gis,rag,...")
+	extListCmd.Flags().StringSliceVar(&extRequire, "require", nil, "list extensions that require given extensions")
+	extListCmd.Flags().BoolVar(&ext.WideDesc, "wide-desc", false, "print full descriptions, wrapped instead of truncated")
+	extListCmd.Flags().BoolVar(&ext.StrictVersion, "strict-version", false, "check enabled repos for a package matching installed pg minor version before showing avail")
+	extListCmd.Flags().BoolVar(&extInstalled, "installed-only", false, "only list extensions installed on target PostgreSQL")
+	extListCmd.Flags().BoolVar(&extInstalled, "installed", false, "only search installed extensions, same as --installed-only")
+	extListCmd.Flags().IntVar(&ext.ListWidth, "width", 0, "output width to fit description in (terminal width by default)")
+	extListCmd.Flags().StringSliceVar(&extLicense, "license", nil, "filter extensions by license: MIT,PostgreSQL,Apache-2.0,...")
+	extListCmd.Flags().StringVar(&extHasPackage, "has-package", "", "only extensions with a binary package: rpm, deb, auto (current os)")
+	extListCmd.Flags().StringVar(&extNoPackage, "no-package", "", "only source-only extensions without package: --no-package=rpm|deb, current os if no value")
+	extListCmd.Flags().Lookup("no-package").NoOptDefVal = "auto"
+	extListCmd.MarkFlagsMutuallyExclusive("has-package", "no-package")
+	extListCmd.Flags().StringVar(&extOutputFile, "output-file", "", "write output to file atomically")
+	extInfoCmd.Flags().StringVar(&extOutputFile, "output-file", "", "write output to file atomically")
+	extInfoCmd.Flags().StringVar(&extOpen, "open", "", "open extension link in browser: home, summary, source")
+	extInfoCmd.Flags().Lookup("open").NoOptDefVal = "home"
+	extInfoCmd.Flags().StringVarP(&extOutput, "output", "o", "table", "output format: table, json")
+	extListCmd.Flags().StringVarP(&extOutput, "output", "o", "table", "output format: table, json")
+	extInfoCmd.Flags().BoolVar(&extInfoDeb, "deb", false, "print deb package metadata only")
+	extInfoCmd.Flags().BoolVar(&extInfoRpm, "rpm", false, "print rpm package metadata only")
+	extInfoCmd.Flags().BoolVar(&extHistory, "history", false, "list available versions across pg major versions")
+	extInfoCmd.Flags().BoolVar(&extIgnoreMissing, "ignore-missing", false, "report unknown extensions at the end, without non-zero exit")
+	extInfoCmd.Flags().BoolVar(&extInfoAll, "all", false, "print every field as key: value lines, including resolved package")
+	extInfoCmd.Flags().BoolVar(&extRelated, "related", false, "show extensions of the same category or sharing requires")
+	extInfoCmd.Flags().BoolVar(&extInfoAllMatches, "all-matches", false, "show all candidates if a partial name matches multiple extensions")
+	extInfoCmd.Flags().BoolVar(&extShowFiles, "show-files", false, "list files installed by extension packages")
+	extInfoCmd.Flags().BoolVar(&extExamples, "examples", false, "print example commands to install and create extension")
+	extInfoCmd.Flags().BoolVar(&extMinimal, "minimal", false, "print a one-line summary per extension")
+	extInfoCmd.Flags().BoolVar(&extNoBox, "no-box", false, "print plain key: value layout without box")
+	extInfoCmd.Flags().IntVar(&ext.InfoWidth, "width", 0, "info box width, 78 by default")
+	extInfoCmd.Flags().BoolVar(&extJSONSchema, "json-schema", false, "print json schema of extension json output")
+	extResolveCmd.Flags().StringVarP(&extOutput, "output", "o", "table", "output format: table, json")
+	extSelfTestCmd.Flags().StringVarP(&extOutput, "output", "o", "table", "output format: table, json")
+	extCatalogStatsCmd.Flags().StringVarP(&extOutput, "output", "o", "table", "output format: table, json")
+	extSizeCmd.Flags().StringVarP(&extOutput, "output", "o", "table", "output format: table, json")
+	extHistoryCmd.Flags().StringVarP(&extOutput, "output", "o", "table", "output format: table, json")
+	extExistsCmd.Flags().BoolVar(&extPrint, "print", false, "print canonical names of found extensions")
+	extExistsCmd.Flags().BoolVar(&extInstalled, "installed", false, "extension must also be installed on target PostgreSQL")
+	extEditPreloadCmd.Flags().StringVar(&ext.PreloadConfFile, "conf", "", "postgresql.conf to edit instead of ALTER SYSTEM")
+	extRollbackCmd.Flags().BoolVarP(&extYes, "yes", "y", false, "auto confirm rollback")
+	extRollbackCmd.Flags().IntVar(&ext.RollbackTo, "to", 0, "roll back to this history entry id, inclusive")
+	extHistoryCmd.Flags().StringVar(&extSince, "since", "", "only show operations since a duration ago (12h, 7d) or a date")
+	extMirrorCmd.Flags().IntVar(&extMirrorPg, "pg", 0, "postgres major version to mirror")
+	extMirrorCmd.Flags().StringVar(&extMirrorArch, "arch", "", "target arch: x86_64, aarch64 (current arch by default)")
+	extMirrorCmd.Flags().StringVarP(&extMirrorDir, "dir", "d", "./mirror", "mirror directory")
+	extMirrorCmd.Flags().IntVarP(&extMirrorJobs, "jobs", "j", 4, "concurrent downloads")
+	extMirrorCmd.Flags().BoolVar(&extMirrorIndex, "index", false, "generate repo index with createrepo / dpkg-scanpackages")
 	extStatusCmd.Flags().BoolVarP(&extShowContrib, "contrib", "c", false, "show contrib extensions too")
+	extStatusCmd.Flags().StringVar(&extFormat, "format", "", extFormatUsage)
+	extStatusCmd.Flags().StringSliceVar(&extDiffDB, "diff-db", nil, "compare enabled extensions of databases: db1,db2")
+	extStatusCmd.Flags().IntVar(&ext.ListWidth, "width", 0, "output width to fit description in (terminal width by default)")
+	extStatusCmd.Flags().StringVarP(&extStatusDB, "dbname", "d", "", "show extensions created in given database")
+	extStatusCmd.Flags().BoolVar(&ext.StatusAge, "age", false, "show how long ago extension packages were installed")
+	extStatusCmd.Flags().StringVarP(&extOutput, "output", "o", "table", "output format: table, json")
+	extStatusCmd.Flags().BoolVar(&extPrometheus, "prometheus", false, "print extension metrics in prometheus text format")
+	extStatusCmd.Flags().StringVar(&extTextfileDir, "textfile-dir", "", "write prometheus metrics into node_exporter textfile collector dir")
+	extCmd.PersistentFlags().StringVar(&ext.CacheDir, "cache-dir", "", "package cache dir (~/.cache/pig/packages by default)")
+	extCmd.PersistentFlags().StringVar(&ext.Backend, "backend", "", "package backend: apt, dnf, yum or a registered one (by os type if empty)")
+	extCmd.PersistentFlags().BoolVar(&ext.LockNoWait, "no-wait", false, "fail immediately if another pig is running")
+	extCmd.PersistentFlags().DurationVar(&ext.LockTimeout, "wait", ext.LockTimeout, "max time to wait for another running pig")
 	extAddCmd.Flags().BoolVarP(&extYes, "yes", "y", false, "auto confirm install")
+	extAddCmd.Flags().BoolVar(&ext.CreateExtension, "create", false, "run CREATE EXTENSION after install")
+	extAddCmd.Flags().StringVarP(&ext.CreateDatabase, "dbname", "d", "", "database to create extensions in, with --create")
+	extAddCmd.Flags().StringVar(&ext.TargetSchema, "to-schema", "", "schema to create relocatable extensions in, with --create")
+	extAddCmd.Flags().StringVar(&ext.PostCreateSQL, "post-create-sql", "", "sql file to run in the same connection after --create")
+	extAddCmd.Flags().StringSliceVar(&extGroups, "group", nil, "install named bundles: gis-stack,rag-stack,...")
+	extAddCmd.Flags().StringSliceVar(&ext.EnableRepos, "enable-repo", nil, "enable repo during this install (dnf --enablerepo, apt -t)")
+	extAddCmd.Flags().StringSliceVar(&ext.DisableRepos, "disable-repo", nil, "disable repo during this install (dnf --disablerepo)")
+	extAddCmd.Flags().BoolVar(&ext.ExcludeRecommends, "exclude-recommends", false, "skip recommended packages (apt --no-install-recommends, dnf install_weak_deps=False)")
+	extAddCmd.Flags().StringVar(&ext.PostInstallHook, "post-install-hook", "", "command to run after install, with PIG_INSTALLED_EXTS env")
+	extAddCmd.Flags().BoolVar(&ext.IgnoreHookErrors, "ignore-hook-errors", false, "do not fail if post install hook exits non-zero")
+	extAddCmd.Flags().BoolVar(&ext.UseCache, "download-only-if-missing", false, "install from package cache, download missing packages into cache first")
+	extAddCmd.Flags().IntVarP(&ext.Jobs, "jobs", "j", 4, "concurrent package downloads, needs --download-only-if-missing except on dnf")
+	extAddCmd.Flags().BoolVar(&ext.Verbose, "verbose", false, "include package manager stderr in install failure, log package signers")
+	extAddCmd.Flags().BoolVar(&ext.CheckSignatures, "check-signatures", true, "enforce signature verification of signed repos (gpgcheck on repos with gpgkey / apt)")
+	extAddCmd.Flags().StringVar(&ext.PreferVersion, "prefer-version", "exact", "if not available for the pg version: exact (fail), nearest (older pg), or a major version")
+	extAddCmd.Flags().BoolVar(&ext.AllowUnsigned, "allow-unsigned", false, "explicitly allow installing unsigned packages")
+	extAddCmd.Flags().BoolVar(&ext.Summary, "summary", true, "print a summary line after install")
+	extAddCmd.Flags().StringVar(&ext.RepoURL, "repo-url", "", "install from given repo url instead of catalog repo (untrusted)")
+	extAddCmd.Flags().StringVar(&ext.ReportFile, "report", "", "write json install report to file")
+	extAddCmd.Flags().BoolVar(&ext.ShowPreloadDiff, "show-preload-diff", false, "print shared_preload_libraries before and after adding installed extensions")
+	extAddCmd.Flags().BoolVar(&ext.EditPreload, "edit-preload", false, "add installed extensions that need loading to shared_preload_libraries")
+	extAddCmd.Flags().StringVar(&ext.LogFile, "log-file", "", "append full package manager output to file")
+	extAddCmd.Flags().BoolVar(&ext.DependencyOrder, "dependency-order", false, "install and create requested extensions after the ones they require")
+	extAddCmd.Flags().BoolVar(&ext.RestartService, "restart", false, "restart PostgreSQL after install if recommended (with confirmation)")
+	extUpgradePgCmd.Flags().IntVar(&extUpgradeFrom, "from", 0, "source pg major version")
+	extUpgradePgCmd.Flags().IntVar(&extUpgradeTo, "to", 0, "target pg major version")
+	extUpgradePgCmd.Flags().BoolVarP(&extYes, "yes", "y", false, "auto confirm install")
+	extUpgradePgCmd.Flags().StringVar(&ext.ReportFile, "report", "", "write json install report to file")
+	_ = extUpgradePgCmd.MarkFlagRequired("from")
+	_ = extUpgradePgCmd.MarkFlagRequired("to")
+	extRmCmd.Flags().StringVar(&ext.ReportFile, "report", "", "write json remove report to file")
+	extUpdateCmd.Flags().StringVar(&ext.ReportFile, "report", "", "write json update report to file")
+	extAddCmd.Flags().BoolVarP(&ext.Quiet, "quiet", "q", false, "suppress install summary table")
+	extUpgradePgCmd.Flags().BoolVarP(&ext.Quiet, "quiet", "q", false, "suppress install summary table")
+	extAddCmd.Flags().BoolVar(&ext.SimulateResolve, "simulate-resolve", false, "print packages a dry-run would install (dependencies included) without installing")
 	extRmCmd.Flags().BoolVarP(&extYes, "yes", "y", false, "auto confirm removal")
+	extRmCmd.Flags().BoolVar(&ext.AllowRemoveServer, "allow-remove-server", false, "allow removal that also removes PostgreSQL server packages")
+	extRmCmd.Flags().BoolVar(&ext.Purge, "purge", false, "also remove config files and extension leftovers")
+	extRmCmd.Flags().BoolVar(&extKeepConfig, "keep-config", true, "keep package config files and report them (default, opposite of --purge)")
+	extRmCmd.MarkFlagsMutuallyExclusive("purge", "keep-config")
 	extUpdateCmd.Flags().BoolVarP(&extYes, "yes", "y", false, "auto confirm update")
+	extUpdateCmd.Flags().BoolVar(&extUpdateInDB, "in-db", false, "run ALTER EXTENSION UPDATE in databases after package update")
+	extUpdateCmd.Flags().StringSliceVarP(&ext.UpdateDatabases, "dbname", "d", nil, "databases to update extensions in, with --in-db")
 
 	extCmd.AddCommand(extAddCmd)
 	extCmd.AddCommand(extRmCmd)
@@ -243,4 +1486,44 @@ func init() {
 	extCmd.AddCommand(extScanCmd)
 	extCmd.AddCommand(extUpdateCmd)
 	extCmd.AddCommand(extStatusCmd)
+	extCmd.AddCommand(extSizeCmd)
+	extCmd.AddCommand(extMirrorCmd)
+	extCmd.AddCommand(extResolveCmd)
+	extCmd.AddCommand(extPendingRestartCmd)
+	extCmd.AddCommand(extSelfTestCmd)
+	extCmd.AddCommand(extUpgradePgCmd)
+	extCmd.AddCommand(extLicensesCmd)
+	extCmd.AddCommand(extCatalogCmd)
+	extCatalogCmd.AddCommand(extCatalogStatsCmd)
+	extCmd.AddCommand(extCacheCmd)
+	extCacheCmd.AddCommand(extCacheInfoCmd)
+	extCacheCmd.AddCommand(extCacheCleanCmd)
+	extCmd.AddCommand(extPinCmd)
+	extCmd.AddCommand(extUnpinCmd)
+	extCmd.AddCommand(extConfigCmd)
+	extConfigCmd.AddCommand(extConfigListCmd)
+	extConfigCmd.AddCommand(extConfigGetCmd)
+	extConfigCmd.AddCommand(extConfigSetCmd)
+	extCmd.AddCommand(extValidateManifestCmd)
+	extCmd.AddCommand(extHistoryCmd)
+	extCmd.AddCommand(extRollbackCmd)
+	extCmd.AddCommand(extEditPreloadCmd)
+	extCmd.AddCommand(extExistsCmd)
+
+	// argument completion: install from catalog, remove & update from installed extensions
+	extAddCmd.ValidArgsFunction = extCompleteCatalog
+	extRmCmd.ValidArgsFunction = extCompleteInstalled
+	extUpdateCmd.ValidArgsFunction = extCompleteInstalled
+
+	// flag value completion
+	_ = extListCmd.RegisterFlagCompletionFunc("category", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return ext.Catalog.Categories(), cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = extCmd.RegisterFlagCompletionFunc("version", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var vers []string
+		for _, v := range ext.PostgresActiveMajorVersions {
+			vers = append(vers, strconv.Itoa(v))
+		}
+		return vers, cobra.ShellCompDirectiveNoFileComp
+	})
 }
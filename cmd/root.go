@@ -7,16 +7,17 @@ import (
 	"fmt"
 	"os"
 	"pig/internal/config"
+	"pig/internal/utils"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // log level parameters
 var (
 	logLevel  string
 	logPath   string
+	logFormat string
 	inventory string
 	debug     bool
 )
@@ -45,15 +46,15 @@ func initAll() error {
 	if debug {
 		logLevel = "debug"
 	}
-	if err := initLogger(logLevel, logPath); err != nil {
+	if err := initLogger(logLevel, logPath, logFormat); err != nil {
 		return err
 	}
 	config.InitConfig(inventory)
 	return nil
 }
 
-// initLogger will init logger according to logLevel and logPath
-func initLogger(level string, path string) error {
+// initLogger will init logger according to logLevel, logPath and logFormat
+func initLogger(level string, path string, format string) error {
 	lvl, err := logrus.ParseLevel(level)
 	if err != nil {
 		lvl = logrus.InfoLevel
@@ -82,13 +83,22 @@ func initLogger(level string, path string) error {
 
 		logrus.Debugf("Stderr logger init at level %s", lvl.String())
 	}
+
+	// override formatter with json if specified
+	switch format {
+	case "", "text":
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		logrus.Debugf("logger format set to json")
+	default:
+		logrus.Warnf("invalid log format: %q, fall back to default 'text'", format)
+	}
 	return nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	fmt.Println(viper.GetString("all.vars.region"))
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
@@ -101,6 +111,8 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug mode")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "log level: debug, info, warn, error, fatal, panic")
 	rootCmd.PersistentFlags().StringVar(&logPath, "log-path", "", "log file path, terminal by default")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "log format: text, json")
+	rootCmd.PersistentFlags().BoolVar(&utils.NoColor, "no-color", false, "disable colored output")
 	rootCmd.PersistentFlags().StringVarP(&inventory, "inventory", "i", "", "config inventory path")
 
 	rootCmd.AddGroup(
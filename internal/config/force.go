@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	OSForced   bool   // OS is overridden by --force-os / --os-version
+	RealOSType string // detected OS type before override
+	RealOSCode string // detected OS code before override
+)
+
+// ForceOS overrides the detected OS family & version for package resolution purpose
+// family could be debian, ubuntu, rhel (or el compatible vendors), current family is used if empty
+func ForceOS(family, version string) error {
+	if family == "" {
+		family = OSVendor
+	}
+	vendor := strings.ToLower(family)
+	var osType, prefix, defaultVersion string
+	switch vendor {
+	case "debian", "deb":
+		vendor, osType, prefix, defaultVersion = "debian", DistroDEB, "d", "12"
+	case "ubuntu":
+		osType, prefix, defaultVersion = DistroDEB, "u", "22"
+	case "rhel", "el", "rpm", "rocky", "almalinux", "centos", "ol":
+		vendor, osType, prefix, defaultVersion = "rhel", DistroEL, "el", "9"
+	case "suse", "sles", "opensuse":
+		return fmt.Errorf("os family %s is not supported yet", family)
+	default:
+		return fmt.Errorf("unknown os family %q, should be debian, ubuntu, or rhel", family)
+	}
+	if version == "" {
+		version = defaultVersion
+	}
+	major := strings.Split(version, ".")[0]
+	majorNum, err := strconv.Atoi(major)
+	if err != nil {
+		return fmt.Errorf("invalid os version %q", version)
+	}
+	if !OSForced {
+		RealOSType, RealOSCode = OSType, OSCode
+	}
+	OSForced = true
+	OSType, OSVendor, OSVersion, OSMajor, OSVersionFull = osType, vendor, major, majorNum, version
+	OSCode = prefix + major
+	if OSType == DistroEL {
+		OSVersionCode = OSCode
+	}
+	logrus.Debugf("force OS: code=%s type=%s vendor=%s version=%s (real: %s)", OSCode, OSType, OSVendor, OSVersion, RealOSCode)
+	return nil
+}
+
+// ForcedOSMismatch reports whether OS is forced to a distribution other than the real one
+func ForcedOSMismatch() bool {
+	return OSForced && (OSType != RealOSType || OSCode != RealOSCode)
+}
@@ -0,0 +1,40 @@
+package config
+
+import "testing"
+
+func TestForceOS(t *testing.T) {
+	defer func(osType, osCode, vendor, version string) {
+		OSType, OSCode, OSVendor, OSVersion = osType, osCode, vendor, version
+		OSForced, RealOSType, RealOSCode = false, "", ""
+	}(OSType, OSCode, OSVendor, OSVersion)
+
+	OSType, OSCode, OSVendor, OSVersion = DistroDEB, "d12", "debian", "12"
+	OSForced = false
+
+	if err := ForceOS("debian", "12"); err != nil {
+		t.Fatalf("ForceOS(debian, 12) error: %v", err)
+	}
+	if ForcedOSMismatch() {
+		t.Errorf("forcing the real os should not be a mismatch")
+	}
+	if err := ForceOS("rhel", "8.10"); err != nil {
+		t.Fatalf("ForceOS(rhel, 8.10) error: %v", err)
+	}
+	if OSType != DistroEL || OSCode != "el8" || OSMajor != 8 {
+		t.Errorf("got type=%s code=%s major=%d, want rpm el8 8", OSType, OSCode, OSMajor)
+	}
+	if !ForcedOSMismatch() || RealOSCode != "d12" {
+		t.Errorf("expect mismatch with real os d12, got real=%s", RealOSCode)
+	}
+	if err := ForceOS("ubuntu", ""); err != nil || OSCode != "u22" {
+		t.Errorf("ForceOS(ubuntu) = %s, %v, want u22", OSCode, err)
+	}
+	for _, family := range []string{"suse", "windows"} {
+		if err := ForceOS(family, ""); err == nil {
+			t.Errorf("ForceOS(%s) should fail", family)
+		}
+	}
+	if err := ForceOS("debian", "bookworm"); err == nil {
+		t.Errorf("ForceOS with non-numeric version should fail")
+	}
+}
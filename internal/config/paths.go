@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CacheDir is the pig cache dir ($XDG_CACHE_HOME/pig or ~/.cache/pig)
+var CacheDir string
+
+// ConfigPath returns the path of a file under pig config dir
+func ConfigPath(name string) string {
+	return filepath.Join(ConfigDir, name)
+}
+
+// CachePath returns the path of a file under pig cache dir
+func CachePath(name string) string {
+	return filepath.Join(CacheDir, name)
+}
+
+// ResolvePaths sets ConfigDir and CacheDir according to XDG base directory spec
+// the legacy ~/.pig is still used as config dir if it exists and the XDG one does not
+func ResolvePaths(home string) {
+	ConfigDir = filepath.Join(xdgDir("XDG_CONFIG_HOME", filepath.Join(home, ".config")), "pig")
+	CacheDir = filepath.Join(xdgDir("XDG_CACHE_HOME", filepath.Join(home, ".cache")), "pig")
+	legacy := filepath.Join(home, ".pig")
+	if _, err := os.Stat(ConfigDir); os.IsNotExist(err) {
+		if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+			logrus.Debugf("use legacy config dir %s, move it to %s to follow XDG spec", legacy, ConfigDir)
+			ConfigDir = legacy
+		}
+	}
+	ConfigFile = ConfigPath("config.yml")
+}
+
+// xdgDir returns the absolute dir from environment variable, or fallback if unset or relative
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return dir
+	}
+	return fallback
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePaths(t *testing.T) {
+	home := t.TempDir()
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	ResolvePaths(home)
+	if want := filepath.Join(home, ".config", "pig"); ConfigDir != want {
+		t.Errorf("ConfigDir = %s, want %s", ConfigDir, want)
+	}
+	if want := filepath.Join(home, ".cache", "pig"); CacheDir != want {
+		t.Errorf("CacheDir = %s, want %s", CacheDir, want)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+	t.Setenv("XDG_CACHE_HOME", "relative/cache") // relative path is ignored per spec
+	ResolvePaths(home)
+	if want := filepath.Join(home, "xdg-config", "pig"); ConfigDir != want {
+		t.Errorf("ConfigDir = %s, want %s", ConfigDir, want)
+	}
+	if want := filepath.Join(home, ".cache", "pig"); CacheDir != want {
+		t.Errorf("CacheDir = %s, want %s", CacheDir, want)
+	}
+	if want := filepath.Join(home, "xdg-config", "pig", "config.yml"); ConfigFile != want {
+		t.Errorf("ConfigFile = %s, want %s", ConfigFile, want)
+	}
+
+	// legacy ~/.pig is used if the xdg config dir does not exist
+	if err := os.Mkdir(filepath.Join(home, ".pig"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	ResolvePaths(home)
+	if want := filepath.Join(home, ".pig"); ConfigDir != want {
+		t.Errorf("ConfigDir = %s, want legacy %s", ConfigDir, want)
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Setting is a key of pig config file that could be managed with pig ext config
+type Setting struct {
+	Key  string
+	Type string // string, int, bool, list
+	Desc string
+}
+
+// Settings are the known keys of pig config file
+var Settings = []Setting{
+	{"pg_version", "int", "default PostgreSQL major version when -v is not given"},
+	{"yes", "bool", "auto confirm install, remove and update"},
+	{"exclude_recommends", "bool", "do not install recommended packages (apt) or weak dependencies (dnf)"},
+	{"output", "string", "default output format: table, json"},
+	{"pg_roots", "list", "extra postgres search roots, comma separated"},
+	{"cache_dir", "string", "package cache directory"},
+	{"proxy", "string", "http(s) proxy for downloads and package managers"},
+}
+
+// LookupSetting returns the known setting of given key
+func LookupSetting(key string) (Setting, error) {
+	for _, s := range Settings {
+		if s.Key == key {
+			return s, nil
+		}
+	}
+	var keys []string
+	for _, s := range Settings {
+		keys = append(keys, s.Key)
+	}
+	return Setting{}, fmt.Errorf("unknown config key %q, should be one of: %s", key, strings.Join(keys, ", "))
+}
+
+// GetSetting returns the current value of a setting as text, lists are comma separated
+func GetSetting(key string) (string, error) {
+	s, err := LookupSetting(key)
+	if err != nil {
+		return "", err
+	}
+	if s.Type == "list" {
+		return strings.Join(viper.GetStringSlice(key), ","), nil
+	}
+	return viper.GetString(key), nil
+}
+
+// SetSetting validates and writes a setting into the config file, comments and other keys are kept
+func SetSetting(key, value string) error {
+	s, err := LookupSetting(key)
+	if err != nil {
+		return err
+	}
+	node, err := settingNode(s, value)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(ConfigFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file %s: %v", ConfigFile, err)
+	}
+	data, err = setYAMLKey(data, key, node)
+	if err != nil {
+		return fmt.Errorf("failed to update config file %s: %v", ConfigFile, err)
+	}
+	if err := writeFileAtomic(ConfigFile, data); err != nil {
+		return err
+	}
+	if s.Type == "list" {
+		var items []string
+		for _, item := range node.Content {
+			items = append(items, item.Value)
+		}
+		viper.Set(key, items)
+	} else {
+		viper.Set(key, node.Value)
+	}
+	return nil
+}
+
+// settingNode converts a setting value into a yaml node of its type
+func settingNode(s Setting, value string) (*yaml.Node, error) {
+	switch s.Type {
+	case "int":
+		if _, err := strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("invalid %s %q, should be an integer", s.Key, value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}, nil
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q, should be true or false", s.Key, value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+	case "list":
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+			}
+		}
+		return seq, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+}
+
+// setYAMLKey sets a top level key of a yaml document, keeping comments and the order of other keys
+func setYAMLKey(data []byte, key string, value *yaml.Node) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("top level of config file is not a mapping")
+	}
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			value.LineComment = root.Content[i+1].LineComment
+			root.Content[i+1] = value
+			found = true
+		}
+	}
+	if !found {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+	var buf strings.Builder
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	return []byte(buf.String()), nil
+}
+
+// writeFileAtomic writes data into a temp file beside path, then renames it to path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	_ = os.Chmod(tmp.Name(), 0644)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetYAMLKey(t *testing.T) {
+	data := "# pig config\nlicense: \"\" # keep me\ncache_dir: /tmp/c # cache\n"
+	s, _ := LookupSetting("cache_dir")
+	node, _ := settingNode(s, "/tmp/d")
+	out, err := setYAMLKey([]byte(data), "cache_dir", node)
+	if err != nil {
+		t.Fatalf("setYAMLKey() error = %v", err)
+	}
+	s, _ = LookupSetting("pg_roots")
+	node, _ = settingNode(s, "/opt/a, /opt/b")
+	if out, err = setYAMLKey(out, "pg_roots", node); err != nil {
+		t.Fatalf("setYAMLKey() error = %v", err)
+	}
+	want := "# pig config\nlicense: \"\" # keep me\ncache_dir: /tmp/d # cache\npg_roots:\n  - /opt/a\n  - /opt/b\n"
+	if string(out) != want {
+		t.Errorf("setYAMLKey() =\n%s\nwant\n%s", out, want)
+	}
+	s, _ = LookupSetting("yes")
+	node, _ = settingNode(s, "1")
+	if out, err = setYAMLKey(nil, "yes", node); err != nil || strings.TrimSpace(string(out)) != "yes: true" {
+		t.Errorf("setYAMLKey(empty) = %q, %v", out, err)
+	}
+}
+
+func TestSettingNode(t *testing.T) {
+	for _, tt := range []struct{ key, value string }{{"pg_version", "x"}, {"yes", "maybe"}} {
+		s, _ := LookupSetting(tt.key)
+		if _, err := settingNode(s, tt.value); err == nil {
+			t.Errorf("settingNode(%s, %s) should fail", tt.key, tt.value)
+		}
+	}
+	if _, err := LookupSetting("nothing"); err == nil {
+		t.Errorf("LookupSetting(nothing) should fail")
+	}
+}
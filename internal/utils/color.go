@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// NoColor disables colored output
+var NoColor = false
+
+// ANSI color codes
+const (
+	ColorRed    = "\033[31m"
+	ColorGreen  = "\033[32m"
+	ColorYellow = "\033[33m"
+	ColorReset  = "\033[0m"
+	ColorMatch  = "\033[1;31m" // bold red, as grep highlights matches
+)
+
+// ColorEnabled reports whether colored output should be used: not disabled by --no-color or NO_COLOR,
+// and stdout is a terminal rather than a file or pipe
+func ColorEnabled() bool {
+	if NoColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Colorize wraps text with the ANSI color if color is enabled
+func Colorize(color, text string) string {
+	if !ColorEnabled() {
+		return text
+	}
+	return color + text + ColorReset
+}
+
+// Highlight colors every case-insensitive occurrence of query in text if color is enabled
+func Highlight(text, query string) string {
+	if query == "" || !ColorEnabled() {
+		return text
+	}
+	lower, q := strings.ToLower(text), strings.ToLower(query)
+	if len(lower) != len(text) {
+		return text // lowered text has different byte offsets, skip rather than corrupt it
+	}
+	var buf strings.Builder
+	for {
+		i := strings.Index(lower, q)
+		if i < 0 {
+			buf.WriteString(text)
+			return buf.String()
+		}
+		buf.WriteString(text[:i] + ColorMatch + text[i:i+len(q)] + ColorReset)
+		text, lower = text[i+len(q):], lower[i+len(q):]
+	}
+}
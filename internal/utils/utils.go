@@ -1,17 +1,28 @@
 package utils
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"pig/internal/config"
+	"runtime"
 	"strings"
+	"syscall"
 )
 
 var (
 
 	// TrySudo is a flag to try to run a command with sudo
 	TrySudo = false
+
+	// CommandLog receives a copy of the command line, stdout and stderr of sudo commands if not nil
+	CommandLog io.Writer
 )
 
 // ShellCommand runs a command without sudo
@@ -31,8 +42,14 @@ func ShellCommand(args []string) error {
 
 // SudoCommand runs a command with sudo if the current user is not root
 func SudoCommand(args []string) error {
+	_, err := SudoCommandTail(args, 0)
+	return err
+}
+
+// SudoCommandTail runs a command like SudoCommand, and also returns the last n lines of its stderr
+func SudoCommandTail(args []string, n int) ([]string, error) {
 	if len(args) == 0 {
-		return fmt.Errorf("no command to run")
+		return nil, fmt.Errorf("no command to run")
 	}
 	if config.CurrentUser != "root" {
 		// insert sudo as first cmd arg
@@ -44,7 +61,201 @@ func SudoCommand(args []string) error {
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	tail := &tailWriter{max: n}
+	if n > 0 {
+		cmd.Stderr = io.MultiWriter(os.Stderr, tail)
+	}
+	if CommandLog != nil {
+		fmt.Fprintf(CommandLog, "$ %s\n", strings.Join(args, " "))
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, CommandLog)
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, CommandLog)
+	}
+	err := runUninterrupted(cmd)
+	if CommandLog != nil && err != nil {
+		fmt.Fprintf(CommandLog, "# %s: %v\n", args[0], err)
+	}
+	if n <= 0 {
+		return nil, err
+	}
+	return tail.Lines(), err
+}
+
+// SudoCommandOutput runs a command with sudo if not root like SudoCommand, and returns its stdout
+func SudoCommandOutput(args []string) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command to run")
+	}
+	if config.CurrentUser != "root" {
+		args = append([]string{"sudo"}, args...)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	return cmd.Output()
+}
+
+// ErrInterrupted is returned when a command is interrupted by SIGINT / SIGTERM
+var ErrInterrupted = errors.New("interrupted")
+
+// runUninterrupted runs the command and waits for it even if pig is interrupted, so package manager
+// transactions are not abandoned with database locks held. The child receives the terminal's SIGINT itself,
+// a second signal terminates it with SIGTERM. ErrInterrupted is returned once the child exits.
+func runUninterrupted(cmd *exec.Cmd) error {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	interrupted := 0
+	for {
+		select {
+		case <-sigs:
+			interrupted++
+			if interrupted == 1 {
+				fmt.Fprintf(os.Stderr, "\ninterrupted, waiting for %s to finish, interrupt again to terminate it\n", cmd.Args[0])
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "\nterminating %s\n", cmd.Args[0])
+			_ = cmd.Process.Signal(syscall.SIGTERM)
+		case err := <-done:
+			if interrupted > 0 {
+				if err != nil {
+					return fmt.Errorf("%w: %v", ErrInterrupted, err)
+				}
+				return ErrInterrupted
+			}
+			return err
+		}
+	}
+}
+
+// tailWriter keeps the last max lines written to it
+type tailWriter struct {
+	max     int
+	lines   []string
+	partial string
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	parts := strings.Split(t.partial+string(p), "\n")
+	t.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		t.lines = append(t.lines, line)
+	}
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+	return len(p), nil
+}
+
+// Lines returns the kept lines, including the last unterminated one
+func (t *tailWriter) Lines() []string {
+	lines := t.lines
+	if t.partial != "" {
+		lines = append(lines, t.partial)
+	}
+	if len(lines) > t.max {
+		lines = lines[len(lines)-t.max:]
+	}
+	return lines
+}
+
+// OpenBrowser opens the url in default browser, print the url instead in headless environment
+func OpenBrowser(url string) error {
+	var opener string
+	switch runtime.GOOS {
+	case "darwin":
+		opener = "open"
+	case "linux":
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			fmt.Println(url)
+			return nil
+		}
+		opener = "xdg-open"
+	}
+	if opener == "" {
+		fmt.Println(url)
+		return nil
+	}
+	if _, err := exec.LookPath(opener); err != nil {
+		fmt.Println(url)
+		return nil
+	}
+	return exec.Command(opener, url).Start()
+}
+
+// Prompt prints the prompt message and reads a line of answer from stdin
+func Prompt(prompt string) string {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return ""
+	}
+	return strings.TrimSpace(answer)
+}
+
+// Confirm asks user for a yes/no confirmation, default to no
+func Confirm(prompt string) bool {
+	answer := strings.ToLower(Prompt(prompt + " [y/N] "))
+	return answer == "y" || answer == "yes"
+}
+
+// PrintJSON prints the value as indented json to stdout
+func PrintJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %v", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// StdoutToFile redirects stdout into a temp file beside path, the returned commit function restores
+// stdout and renames the temp file to path (or removes it when keep is false), so interrupted runs
+// never leave a half-written file. The temp file is also removed if pig is killed by SIGINT / SIGTERM
+// before commit.
+func StdoutToFile(path string) (func(keep bool) error, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file for %s: %v", path, err)
+	}
+	stdout := os.Stdout
+	os.Stdout = tmp
+
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigs:
+			tmp.Close()
+			os.Remove(tmp.Name())
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	return func(keep bool) error {
+		signal.Stop(sigs)
+		close(done)
+		os.Stdout = stdout
+		if !keep {
+			tmp.Close()
+			return os.Remove(tmp.Name())
+		}
+		if err := tmp.Close(); err != nil {
+			os.Remove(tmp.Name())
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		_ = os.Chmod(tmp.Name(), 0644)
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			os.Remove(tmp.Name())
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		return nil
+	}, nil
 }
 
 // PadKV pads a key-value pair with spaces to the right
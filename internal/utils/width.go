@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/text/width"
+)
+
+// RuneWidth returns the terminal column width of a rune: 2 for east asian wide runes, 0 for combining marks
+func RuneWidth(r rune) int {
+	if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || r == '\u200b' {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// DisplayWidth returns the terminal column width of a string, ansi color sequences take no width
+func DisplayWidth(s string) int {
+	n, escape := 0, false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			escape = true
+		case escape:
+			escape = r != 'm'
+		default:
+			n += RuneWidth(r)
+		}
+	}
+	return n
+}
+
+// TruncateWidth cuts a string to at most given display width, wide runes are never split
+func TruncateWidth(s string, n int) string {
+	w := 0
+	for i, r := range s {
+		if w+RuneWidth(r) > n {
+			return s[:i]
+		}
+		w += RuneWidth(r)
+	}
+	return s
+}
+
+// WrapWidth wraps a string into lines of at most given display width at spaces, longer words are broken
+func WrapWidth(s string, n int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		for DisplayWidth(word) > n {
+			if line != "" {
+				lines, line = append(lines, line), ""
+			}
+			head := TruncateWidth(word, n)
+			if head == "" {
+				break
+			}
+			lines, word = append(lines, head), word[len(head):]
+		}
+		switch {
+		case word == "":
+		case line == "":
+			line = word
+		case DisplayWidth(line)+1+DisplayWidth(word) <= n:
+			line += " " + word
+		default:
+			lines, line = append(lines, line), word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// PadWidth pads a string with spaces to the right to given display width, longer strings are kept as is
+func PadWidth(s string, n int) string {
+	if pad := n - DisplayWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// TerminalWidth returns the column width of stdout terminal, COLUMNS env takes precedence
+// 0 is returned if stdout is not a terminal (e.g. piped) and COLUMNS is not set
+func TerminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 {
+		return 0
+	}
+	return int(ws.Col)
+}